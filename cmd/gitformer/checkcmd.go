@@ -0,0 +1,76 @@
+/*
+Copyright (c) 2023 Peach Pie Labs, LLC.
+*/
+
+package gitformer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path"
+
+	pb "github.com/peachpielabs/gitformer/pkg/playbook"
+	"github.com/spf13/cobra"
+)
+
+var checkDir string
+
+func init() {
+	rootCmd.AddCommand(checkCmd)
+
+	addDirFlag(checkCmd, &checkDir, "the directory of the playbook file")
+}
+
+var checkCmd = &cobra.Command{
+	Use:   "check <playbook_file>",
+	Short: "Check generated files for drift",
+	Long: `Check recomputes every output recorded in the manifest from the stored answers and the current templates.
+It fails if a generated file was edited by hand or is stale, and prints a diff per file. Nothing is written.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 {
+			pb.CaptureError(errors.New("provide the filename of the playbook to check. For example:\n `gitformer check playbook.yaml`"))
+			log.Fatal("Provide the filename of the playbook to check. For example:\n `gitformer check playbook.yaml`")
+		}
+		playbook_filepath := args[0]
+		playbook_base_dir := path.Dir(playbook_filepath)
+		output_root := playbook_base_dir
+		if checkDir != "" {
+			output_root = checkDir
+		}
+
+		manifest, err := pb.LoadManifest(output_root)
+		if err != nil {
+			pb.CaptureError(err)
+			log.Fatal(err)
+		}
+
+		failures := 0
+		for _, generation := range manifest.Generations {
+			if generation.Playbook != path.Base(playbook_filepath) {
+				continue
+			}
+			// Only the results are listed, not every file rendered
+			results, err := pb.CheckGeneration(io.Discard, playbook_base_dir, output_root, generation)
+			if err != nil {
+				pb.CaptureError(err)
+				log.Fatal(err)
+			}
+			for _, result := range results {
+				fmt.Printf("%-8v %v (generation %v)\n", result.Status, result.OutputFile, generation.ID)
+				if result.Diff != "" {
+					fmt.Println(result.Diff)
+				}
+				if result.Status != pb.CheckStatusOK {
+					failures++
+				}
+			}
+		}
+
+		if failures > 0 {
+			log.Fatalf("%v generated files are out of date or were modified by hand", failures)
+		}
+		fmt.Println("All generated files are up to date")
+	},
+}
@@ -0,0 +1,204 @@
+/*
+Copyright (c) 2023 Peach Pie Labs, LLC.
+*/
+
+package gitformer
+
+import (
+	"errors"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/peachpielabs/gitformer/pkg/forge"
+	pb "github.com/peachpielabs/gitformer/pkg/playbook"
+)
+
+var (
+	commitFlag      bool
+	branchFlag      string
+	messageFlag     string
+	authorFlag      string
+	allowDirtyFlag  bool
+	pullRequestFlag bool
+	forgeFlag       string
+	forgeURLFlag    string
+	forgeRepoFlag   string
+	remoteFlag      string
+	baseFlag        string
+	pushFlag        bool
+	repoFlag        string
+)
+
+type gitCommit struct {
+	repository  pb.GitRepository
+	branch      string
+	message     string
+	author      string
+	pullRequest *pullRequest
+}
+
+type pullRequest struct {
+	provider forge.Provider
+	request  forge.PullRequest
+}
+
+// prepareCommit resolves the branch name, commit message and author from the
+// flags, the playbook and the defaults, and checks that the commit can be made
+// before any output is written.
+func prepareCommit(playbook pb.Playbook, output_root string, input_data map[string]interface{}, generation pb.Generation, forge_repo string) (gitCommit, error) {
+	options := pb.GitOptions{
+		Branch:        pb.DefaultBranchTemplate,
+		CommitMessage: pb.DefaultCommitMessageTemplate,
+	}
+	if playbook.Git != nil {
+		if playbook.Git.Branch != "" {
+			options.Branch = playbook.Git.Branch
+		}
+		if playbook.Git.CommitMessage != "" {
+			options.CommitMessage = playbook.Git.CommitMessage
+		}
+		options.Author = playbook.Git.Author
+	}
+	if branchFlag != "" {
+		options.Branch = branchFlag
+	}
+	if messageFlag != "" {
+		options.CommitMessage = messageFlag
+	}
+	if authorFlag != "" {
+		options.Author = authorFlag
+	}
+
+	template_data := map[string]interface{}{
+		"generation_id": generation.ID,
+		"playbook_name": playbook.Name,
+		"answers":       generation.Answers,
+	}
+	for key, value := range input_data {
+		template_data[key] = value
+	}
+
+	var commit gitCommit
+	var err error
+	commit.repository, err = pb.OpenGitRepository(output_root)
+	if err != nil {
+		return commit, err
+	}
+	branch, err := pb.RenderText(options.Branch, template_data)
+	if err != nil {
+		return commit, errors.Join(errors.New("invalid branch template"), err)
+	}
+	commit.branch = strings.TrimSpace(branch)
+	commit.message, err = pb.RenderText(options.CommitMessage, template_data)
+	if err != nil {
+		return commit, errors.Join(errors.New("invalid commit message template"), err)
+	}
+	commit.author = options.Author
+	if commit.author != "" {
+		if _, _, err = pb.ParseGitAuthor(commit.author); err != nil {
+			return commit, err
+		}
+	}
+
+	err = commit.repository.ValidateBranch(commit.branch)
+	if err != nil {
+		return commit, err
+	}
+	if !allowDirtyFlag {
+		dirty, err := commit.repository.HasStagedChanges()
+		if err != nil {
+			return commit, err
+		}
+		if dirty {
+			return commit, errors.New("the git index has staged changes. commit or unstage them first, or use --allow-dirty")
+		}
+	}
+
+	if pullRequestFlag {
+		commit.pullRequest, err = preparePullRequest(playbook, commit, template_data, forge_repo)
+		if err != nil {
+			return commit, err
+		}
+	}
+	return commit, nil
+}
+
+func preparePullRequest(playbook pb.Playbook, commit gitCommit, template_data map[string]interface{}, forge_repo string) (*pullRequest, error) {
+	options := pb.PullRequestOptions{
+		Title: pb.DefaultPullRequestTitleTemplate,
+		Body:  pb.DefaultPullRequestBodyTemplate,
+	}
+	if playbook.Git != nil && playbook.Git.PullRequest != nil {
+		if playbook.Git.PullRequest.Title != "" {
+			options.Title = playbook.Git.PullRequest.Title
+		}
+		if playbook.Git.PullRequest.Body != "" {
+			options.Body = playbook.Git.PullRequest.Body
+		}
+	}
+
+	var pr pullRequest
+	var err error
+	pr.provider, err = forge.NewProvider(forgeFlag, forgeURLFlag, "")
+	if err != nil {
+		return nil, err
+	}
+	pr.request.Repository = forge_repo
+	if pr.request.Repository == "" {
+		remoteURL, err := commit.repository.RemoteURL(remoteFlag)
+		if err != nil {
+			return nil, err
+		}
+		pr.request.Repository, err = forge.RepositoryFromRemote(remoteURL)
+		if err != nil {
+			return nil, errors.Join(err, errors.New("use --forge-repo to provide the repository"))
+		}
+	}
+	pr.request.Head = commit.branch
+	pr.request.Base = baseFlag
+	if pr.request.Base == "" {
+		pr.request.Base, err = commit.repository.CurrentBranch()
+		if err != nil {
+			return nil, err
+		}
+	}
+	title, err := pb.RenderText(options.Title, template_data)
+	if err != nil {
+		return nil, errors.Join(errors.New("invalid pull request title template"), err)
+	}
+	pr.request.Title = strings.TrimSpace(title)
+	pr.request.Body, err = pb.RenderText(options.Body, template_data)
+	if err != nil {
+		return nil, errors.Join(errors.New("invalid pull request body template"), err)
+	}
+	return &pr, nil
+}
+
+// run creates the branch and commits the given files, which are relative to
+// root, along with the manifest.
+func (c gitCommit) run(root string, files []string) (string, error) {
+	var paths []string
+	for _, file := range append(files, path.Join(pb.ManifestDir, pb.ManifestFileName)) {
+		absolutePath, err := filepath.Abs(filepath.Join(root, file))
+		if err != nil {
+			return "", err
+		}
+		paths = append(paths, absolutePath)
+	}
+
+	err := c.repository.CreateBranch(c.branch)
+	if err != nil {
+		return "", err
+	}
+	return c.repository.Commit(paths, c.message, c.author)
+}
+
+func (c gitCommit) push() error {
+	return c.repository.Push(remoteFlag, c.branch)
+}
+
+// openPullRequest opens the pull request for the pushed branch, returning its URL.
+func (c gitCommit) openPullRequest() (string, error) {
+	return c.pullRequest.provider.CreatePullRequest(c.pullRequest.request)
+}
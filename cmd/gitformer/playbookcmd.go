@@ -7,9 +7,13 @@ package gitformer
 import (
 	"errors"
 	"fmt"
+	"io"
 	"log"
+	"os"
 	"path"
+	"path/filepath"
 
+	"github.com/peachpielabs/gitformer/pkg/forge"
 	pb "github.com/peachpielabs/gitformer/pkg/playbook"
 	"github.com/spf13/cobra"
 )
@@ -26,6 +30,26 @@ func init() {
 	// Add flags for --overwrite and --append
 	runCmd.PersistentFlags().BoolVarP(&overwriteFlag, "overwrite", "o", false, "Overwrite the output file if it exists")
 	runCmd.PersistentFlags().BoolVarP(&appendFlag, "append", "a", false, "Append to the output file if it exists")
+
+	// Add flags to commit the rendered outputs on a new branch
+	runCmd.PersistentFlags().BoolVar(&commitFlag, "commit", false, "Commit the rendered outputs on a new git branch")
+	runCmd.PersistentFlags().StringVar(&branchFlag, "branch", "", "Template of the branch name (default \""+pb.DefaultBranchTemplate+"\")")
+	runCmd.PersistentFlags().StringVar(&messageFlag, "message", "", "Template of the commit message (default \""+pb.DefaultCommitMessageTemplate+"\")")
+	runCmd.PersistentFlags().StringVar(&authorFlag, "author", "", "Commit author in the format \"Name <email>\"")
+	runCmd.PersistentFlags().BoolVar(&allowDirtyFlag, "allow-dirty", false, "Commit even if the git index has staged changes")
+
+	// Add flags to push the branch and open a pull request
+	runCmd.PersistentFlags().BoolVar(&pullRequestFlag, "pull-request", false, "Push the branch and open a pull request (implies --commit)")
+	runCmd.PersistentFlags().StringVar(&forgeFlag, "forge", forge.GitHub, "Forge hosting the repository: github, gitlab or gitea")
+	runCmd.PersistentFlags().StringVar(&forgeURLFlag, "forge-url", "", "Base URL of the forge API (default: the public instance of the forge)")
+	runCmd.PersistentFlags().StringVar(&forgeRepoFlag, "forge-repo", "", "Repository on the forge, e.g. owner/name (default: derived from the remote URL)")
+	runCmd.PersistentFlags().StringVar(&remoteFlag, "remote", "origin", "Git remote to push the branch to")
+	runCmd.PersistentFlags().StringVar(&baseFlag, "base", "", "Branch the pull request is merged into (default: the current branch)")
+
+	// Add flag to render the outputs into another repository
+	runCmd.PersistentFlags().StringVar(&repoFlag, "repo", "", "Path or URL of the repository to render the outputs into. It is cloned to a temporary directory, and the outputs are committed on a new branch and pushed")
+	runCmd.PersistentFlags().StringVar(&targetsFlag, "targets", "", "YAML file listing repositories to apply the playbook to, with per-repository answers")
+	runCmd.PersistentFlags().IntVar(&concurrencyFlag, "concurrency", 0, fmt.Sprintf("Number of targets processed at the same time (default %v)", defaultTargetConcurrency))
 }
 
 var runCmd = &cobra.Command{
@@ -54,51 +78,394 @@ var runCmd = &cobra.Command{
 			log.Fatal("playbook is not valid: ", err)
 		}
 
-		input_data := make(map[string]interface{})
-		for _, question := range playbook.Questions {
-			for {
-				result, err := pb.PromptForUserInput(question)
-				if err != nil {
-					pb.CaptureError(err)
-					log.Fatal(err)
-				}
-
-				if question.CustomRegexValidation != "" {
-					if err := pb.CustomRegexValidate(result, question.CustomRegexValidation); err != nil {
-						log.Println(err)
-						pb.CaptureError(err)
-						continue
-					}
-				} else if question.Validation != "" {
-					if err := pb.RegexPatternValidate(result, question); err != nil {
-						log.Println(err)
-						pb.CaptureError(err)
-						continue
-					}
-				}
-
-				input_data[question.VariableName] = result
-				break
+		if targetsFlag != "" {
+			if repoFlag != "" {
+				log.Fatal("--repo and --targets cannot be used together")
 			}
-		}
-
-		for _, render := range playbook.Outputs {
-			renderedFileContents, outputFilePath, err := pb.RenderTemplate(playbook_base_dir, input_data, render.TemplateFile, render.OutputFile)
+			targets, err := pb.LoadTargetsFile(targetsFlag)
 			if err != nil {
 				pb.CaptureError(err)
 				log.Fatal(err)
 			}
-
-			err = pb.SaveToOutputFile(outputFilePath, renderedFileContents, overwriteFlag, appendFlag)
+			commitFlag = true
+			pushFlag = true
+			failures, err := runTargets(os.Stdout, playbook, playbook_filepath, targets)
 			if err != nil {
 				pb.CaptureError(err)
 				log.Fatal(err)
 			}
-			fmt.Printf("Output saved successfully to %v\n", outputFilePath)
+			if failures > 0 {
+				log.Fatalf("playbook failed for %v of %v targets", failures, len(targets.Targets))
+			}
+			return
+		}
+
+		err = runPlaybook(playbook, playbook_filepath)
+		if err != nil {
+			pb.CaptureError(err)
+			log.Fatal(err)
 		}
 	},
 }
 
+// runPlaybook answers the questions and writes the outputs, then commits,
+// pushes and opens a pull request as requested. The temporary clone made for
+// --repo is removed before it returns, so it returns errors rather than
+// exiting.
+func runPlaybook(playbook pb.Playbook, playbook_filepath string) error {
+	// Outputs are rendered next to the playbook, or at the root of a
+	// temporary clone of the target repository
+	playbook_base_dir := path.Dir(playbook_filepath)
+	output_root := playbook_base_dir
+	if repoFlag != "" {
+		fmt.Printf("Cloning %v\n", repoFlag)
+		workspace, err := pb.CloneRepository(repoFlag)
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(workspace.Dir)
+		output_root = workspace.Dir
+		commitFlag = true
+		pushFlag = true
+	}
+	if pullRequestFlag {
+		commitFlag = true
+		pushFlag = true
+	}
+
+	input_data, err := collectAnswers(playbook, output_root)
+	if err != nil {
+		return err
+	}
+
+	generation := pb.NewGeneration(path.Base(playbook_filepath), input_data, pb.SecretNames(playbook))
+	var commit gitCommit
+	if commitFlag {
+		commit, err = prepareCommit(playbook, output_root, input_data, generation, forgeRepoFlag)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = writeOutputs(os.Stdout, playbook, playbook_base_dir, output_root, input_data, &generation, true)
+	if err != nil {
+		return err
+	}
+	if repoFlag != "" {
+		// The clone is removed, but the manifest is pushed along with the outputs
+		fmt.Printf("Recorded generation %v in %v of %v\n", generation.ID, filepath.Join(pb.ManifestDir, pb.ManifestFileName), repoFlag)
+	} else {
+		fmt.Printf("Recorded generation %v in %v\n", generation.ID, pb.ManifestPath(output_root))
+	}
+
+	if commitFlag {
+		hash, err := commit.run(output_root, generatedFiles(generation))
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Committed %v on branch %v\n", hash, commit.branch)
+	}
+
+	if pushFlag {
+		err = commit.push()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Pushed branch %v to %v\n", commit.branch, remoteFlag)
+	}
+
+	if commit.pullRequest != nil {
+		url, err := commit.openPullRequest()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Pull request opened: %v\n", url)
+	}
+	return nil
+}
+
+// collectAnswers prompts for every question until the answer passes validation.
+// Questions answered by an environment variable are not prompted for, and an
+// invalid answer from the environment is an error. Answers are transformed
+// before they are validated. Playbook variables are computed as soon as the
+// answers they refer to are known, and the options of select questions are
+// read from output_root.
+func collectAnswers(playbook pb.Playbook, output_root string) (map[string]interface{}, error) {
+	input_data := make(map[string]interface{})
+	evaluateVariables := func() error {
+		if err := pb.EvaluateVariables(playbook, input_data); err != nil {
+			return err
+		}
+		pb.RegisterSecrets(playbook, input_data)
+		return nil
+	}
+	if err := evaluateVariables(); err != nil {
+		return nil, err
+	}
+	for _, question := range playbook.Questions {
+		question, err := pb.RenderQuestion(question, input_data)
+		if err == nil {
+			question, err = pb.ResolveOptions(os.Stdout, question, output_root, input_data)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if result, name, ok := pb.EnvAnswer(question, envPrefixFlag); ok {
+			if question.IsSecret() {
+				pb.RegisterSecret(result)
+			}
+			result, err = transformAnswer(question, result)
+			if err != nil {
+				return nil, err
+			}
+			if err := pb.ValidateAnswer(result, question); err != nil {
+				return nil, fmt.Errorf("invalid answer for %s from environment variable %s: %w", question.VariableName, name, err)
+			}
+			input_data[question.VariableName] = question.AnswerValue(result)
+			if err := evaluateVariables(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		for {
+			result, err := pb.PromptForUserInput(question)
+			if err != nil {
+				return nil, err
+			}
+			if question.IsSecret() {
+				pb.RegisterSecret(result)
+			}
+			result, err = transformAnswer(question, result)
+			if err != nil {
+				return nil, err
+			}
+
+			if err := pb.ValidateAnswer(result, question); err != nil {
+				log.Println(err)
+				pb.CaptureError(err)
+				continue
+			}
+
+			input_data[question.VariableName] = question.AnswerValue(result)
+			break
+		}
+		if err := evaluateVariables(); err != nil {
+			return nil, err
+		}
+	}
+	return input_data, nil
+}
+
+// transformAnswer applies the transforms of a question to an answer, and
+// echoes the answer back when they changed it.
+func transformAnswer(question pb.Question, answer string) (string, error) {
+	transformed, err := pb.TransformAnswer(question, answer)
+	if err != nil {
+		return "", err
+	}
+	if question.IsSecret() {
+		pb.RegisterSecret(transformed)
+	} else if transformed != answer {
+		fmt.Printf("%v: using %q\n", question.VariableName, transformed)
+	}
+	return transformed, nil
+}
+
+// writeOutputs renders the outputs of the playbook into output_root and records
+// them as a generation in the manifest there. When interactive is false, an
+// existing output file is an error unless --overwrite or --append tell what to do.
+// Playbook hooks run around the rendering, postRender hooks on a staged copy of
+// the outputs, and written outputs are rolled back if writing or a postWrite
+// hook fails. Progress and the output of hooks are printed to out.
+func writeOutputs(out io.Writer, playbook pb.Playbook, playbook_base_dir string, output_root string, input_data map[string]interface{}, generation *pb.Generation, interactive bool) error {
+	manifest, err := pb.LoadManifest(output_root)
+	if err != nil {
+		return err
+	}
+	secrets := pb.SecretNames(playbook)
+	hooks := pb.Hooks{}
+	if playbook.Hooks != nil {
+		hooks = *playbook.Hooks
+	}
+
+	err = pb.RunHooks(out, pb.HookStagePreRender, hooks.PreRender, output_root, input_data, nil)
+	if err != nil {
+		return err
+	}
+
+	type renderedOutput struct {
+		output               pb.Output
+		outputFilePath       string
+		renderedFileContents string
+		template_source      string
+		item                 interface{}
+		index                int
+		// template_contents is the output as rendered, before any hook ran
+		template_contents string
+	}
+	var rendered []renderedOutput
+	var output_files []string
+	for _, render := range playbook.Outputs {
+		items := []interface{}{nil}
+		if render.ForEach != "" {
+			items, err = pb.ForEachItems(input_data, render.ForEach)
+			if err != nil {
+				return fmt.Errorf("%v: %w", render.TemplateFile, err)
+			}
+		}
+		item_paths := make(map[string]bool)
+		for index, item := range items {
+			output_data := input_data
+			if render.ForEach != "" {
+				output_data = pb.ItemInputData(input_data, item, index)
+			}
+			enabled, err := pb.EvaluateCondition(render.When, output_data)
+			if err != nil {
+				return fmt.Errorf("%v: %w", render.TemplateFile, err)
+			}
+			if !enabled {
+				if render.ForEach != "" {
+					fmt.Fprintf(out, "skipping template %v for item %v: condition %q is false\n", render.TemplateFile, item, render.When)
+				} else {
+					fmt.Fprintf(out, "skipping template %v: condition %q is false\n", render.TemplateFile, render.When)
+				}
+				continue
+			}
+			renderedFileContents, outputFilePath, err := pb.RenderTemplateTo(out, playbook_base_dir, output_root, output_data, render.TemplateFile, render.OutputFile)
+			if err != nil {
+				return err
+			}
+			if item_paths[outputFilePath] {
+				return fmt.Errorf("%v: more than one item of %v renders to %v. use .item or .index in outputFile", render.TemplateFile, render.ForEach, outputFilePath)
+			}
+			item_paths[outputFilePath] = true
+			renderedFileContents, err = pb.FormatOutput(renderedFileContents, render.Format)
+			if err != nil {
+				return fmt.Errorf("%v: %w", outputFilePath, err)
+			}
+			err = pb.ValidateSyntax(renderedFileContents, render.ValidateAs)
+			if err != nil {
+				return fmt.Errorf("%v: %w", outputFilePath, err)
+			}
+			if render.Schema != "" {
+				err = pb.ValidateSchema(playbook_base_dir, render.Schema, renderedFileContents)
+				if err != nil {
+					return fmt.Errorf("%v: %w", outputFilePath, err)
+				}
+			}
+			template_source, err := pb.ReadTemplateSource(playbook_base_dir, render.TemplateFile)
+			if err != nil {
+				return err
+			}
+			rendered = append(rendered, renderedOutput{render, outputFilePath, renderedFileContents, template_source, item, index, renderedFileContents})
+			relativePath, err := filepath.Rel(output_root, outputFilePath)
+			if err != nil {
+				return err
+			}
+			output_files = append(output_files, relativePath)
+		}
+	}
+
+	if len(hooks.PostRender) > 0 {
+		staged := make(map[string]string, len(rendered))
+		for i, output := range rendered {
+			staged[output_files[i]] = output.renderedFileContents
+		}
+		staging_dir, err := pb.StageOutputs(staged)
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(staging_dir)
+		err = pb.RunHooks(out, pb.HookStagePostRender, hooks.PostRender, staging_dir, input_data, output_files)
+		if err != nil {
+			return err
+		}
+		// postRender hooks may have reformatted the outputs
+		for i := range rendered {
+			contents, err := os.ReadFile(filepath.Join(staging_dir, output_files[i]))
+			if err != nil {
+				return err
+			}
+			rendered[i].renderedFileContents = string(contents)
+		}
+	}
+
+	var backups []pb.FileBackup
+	rollback := func(err error) error {
+		for i := len(backups) - 1; i >= 0; i-- {
+			if restoreErr := pb.RestoreFile(backups[i]); restoreErr != nil {
+				err = errors.Join(err, restoreErr)
+			}
+		}
+		if len(backups) > 0 {
+			fmt.Fprintln(out, "Rolled back the written outputs")
+		}
+		return err
+	}
+	first_file := len(generation.Files)
+	for _, output := range rendered {
+		mode := pb.OutputMode(output.outputFilePath, overwriteFlag, appendFlag)
+		if !interactive && mode != pb.OutputModeCreate && overwriteFlag == appendFlag {
+			return rollback(fmt.Errorf("output file %v already exists. use --overwrite or --append", output.outputFilePath))
+		}
+		backup, err := pb.BackupFile(output.outputFilePath)
+		if err != nil {
+			return rollback(err)
+		}
+		backups = append(backups, backup)
+		err = pb.SaveToOutputFile(output.outputFilePath, output.renderedFileContents, overwriteFlag, appendFlag)
+		if err != nil {
+			return rollback(err)
+		}
+		fmt.Fprintf(out, "Output saved successfully to %v\n", output.outputFilePath)
+
+		generatedFile, err := pb.NewGeneratedFile(output_root, output.output, output.template_source, output.outputFilePath, output.renderedFileContents, mode)
+		if err != nil {
+			return rollback(err)
+		}
+		generatedFile.Secret = pb.UsesSecrets(output.template_source, secrets) || secrets[output.output.ForEach]
+		if output.output.ForEach != "" && !generatedFile.Secret {
+			index := output.index
+			generatedFile.Item, generatedFile.Index = output.item, &index
+		}
+		generation.Files = append(generation.Files, generatedFile)
+	}
+
+	err = pb.RunHooks(out, pb.HookStagePostWrite, hooks.PostWrite, output_root, input_data, output_files)
+	if err != nil {
+		return rollback(err)
+	}
+	// postWrite hooks may have reformatted the outputs. What the hooks made
+	// of the rendered templates is recorded, as check and update do not run
+	// them, unless it holds secrets
+	for i, output := range rendered {
+		file := &generation.Files[first_file+i]
+		if file.Mode == pb.OutputModeAppend {
+			continue
+		}
+		contents, err := os.ReadFile(filepath.Join(output_root, file.OutputFile))
+		if err != nil {
+			return rollback(err)
+		}
+		file.Checksum = pb.Checksum(string(contents))
+		if !file.Secret && string(contents) != output.template_contents {
+			file.Output = string(contents)
+		}
+	}
+
+	manifest.Generations = append(manifest.Generations, *generation)
+	return pb.SaveManifest(output_root, manifest)
+}
+
+// generatedFiles lists the files written by a generation relative to the output root.
+func generatedFiles(generation pb.Generation) []string {
+	var files []string
+	for _, file := range generation.Files {
+		files = append(files, file.OutputFile)
+	}
+	return files
+}
+
 var validateCmd = &cobra.Command{
 	Use:   "validate <playbook_file>",
 	Short: "Validate a playbook",
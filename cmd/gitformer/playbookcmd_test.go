@@ -2,4 +2,93 @@
 Copyright (c) 2023 Peach Pie Labs, LLC.
 */
 
-package gitformer_test
+package gitformer
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	pb "github.com/peachpielabs/gitformer/pkg/playbook"
+)
+
+func TestWriteOutputsHookOutput(t *testing.T) {
+	playbook_base_dir := t.TempDir()
+	output_root := t.TempDir()
+	err := os.WriteFile(filepath.Join(playbook_base_dir, "file.tpl"), []byte("name=\"{{.name}}\"\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	playbook := pb.Playbook{
+		Outputs: []pb.Output{{TemplateFile: "file.tpl", OutputFile: "out.tf"}},
+		// Formats the staged output like terraform fmt would
+		Hooks: &pb.Hooks{PostRender: []pb.Hook{{Command: "sed", Args: []string{"-i", "s/=/ = /", "out.tf"}}}},
+	}
+	input_data := map[string]interface{}{"name": "web"}
+	generation := pb.NewGeneration("playbook.yaml", input_data, nil)
+
+	err = writeOutputs(io.Discard, playbook, playbook_base_dir, output_root, input_data, &generation, false)
+	if err != nil {
+		t.Fatalf("writeOutputs() error = %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join(output_root, "out.tf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "name = \"web\"\n"; string(got) != want {
+		t.Errorf("writeOutputs() wrote %q, want %q", got, want)
+	}
+
+	// The generation is up to date although check does not run the hooks
+	results, err := pb.CheckGeneration(io.Discard, playbook_base_dir, output_root, generation)
+	if err != nil {
+		t.Fatalf("CheckGeneration() error = %v", err)
+	}
+	if results[0].Status != pb.CheckStatusOK {
+		t.Errorf("CheckGeneration() status = %v with diff %q, want %v", results[0].Status, results[0].Diff, pb.CheckStatusOK)
+	}
+}
+
+func TestWriteOutputsPostWriteRollback(t *testing.T) {
+	playbook_base_dir := t.TempDir()
+	output_root := t.TempDir()
+	err := os.WriteFile(filepath.Join(playbook_base_dir, "file.tpl"), []byte("name = \"{{.name}}\"\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(output_root, "existing.tf"), []byte("# kept\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	playbook := pb.Playbook{
+		Outputs: []pb.Output{
+			{TemplateFile: "file.tpl", OutputFile: "terraform/web/main.tf"},
+			{TemplateFile: "file.tpl", OutputFile: "existing.tf"},
+		},
+		Hooks: &pb.Hooks{PostWrite: []pb.Hook{{Command: "false"}}},
+	}
+	input_data := map[string]interface{}{"name": "web"}
+	generation := pb.NewGeneration("playbook.yaml", input_data, nil)
+
+	overwriteFlag = true
+	defer func() { overwriteFlag = false }()
+	err = writeOutputs(io.Discard, playbook, playbook_base_dir, output_root, input_data, &generation, false)
+	if err == nil {
+		t.Fatal("writeOutputs() wanted error")
+	}
+
+	if _, err := os.Stat(filepath.Join(output_root, "terraform")); !os.IsNotExist(err) {
+		t.Errorf("writeOutputs() left the created directory terraform behind")
+	}
+	got, err := os.ReadFile(filepath.Join(output_root, "existing.tf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "# kept\n" {
+		t.Errorf("writeOutputs() left %q in existing.tf, want it restored", got)
+	}
+	if _, err := os.Stat(pb.ManifestPath(output_root)); !os.IsNotExist(err) {
+		t.Errorf("writeOutputs() recorded the rolled back generation")
+	}
+}
@@ -0,0 +1,122 @@
+/*
+Copyright (c) 2023 Peach Pie Labs, LLC.
+*/
+
+package gitformer
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"path/filepath"
+	"strings"
+
+	pb "github.com/peachpielabs/gitformer/pkg/playbook"
+	"github.com/spf13/cobra"
+)
+
+var (
+	removeDir   string
+	removeForce bool
+)
+
+func init() {
+	rootCmd.AddCommand(removeCmd)
+
+	addDirFlag(removeCmd, &removeDir, "the directory under the current one whose manifest records the generation")
+	removeCmd.Flags().BoolVarP(&removeForce, "force", "f", false, "Remove files even if they were modified since the generation")
+}
+
+var removeCmd = &cobra.Command{
+	Use:   "remove <generation_id>",
+	Short: "Remove the outputs of a previous run",
+	Long: `Remove deletes the files created by a previous run of a playbook and strips the blocks it appended to existing files.
+Files modified since the generation are only removed with --force.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 {
+			pb.CaptureError(errors.New("provide the id of the generation to remove. For example:\n `gitformer remove 20230601120000-a1b2c3`"))
+			log.Fatal("Provide the id of the generation to remove. For example:\n `gitformer remove 20230601120000-a1b2c3`")
+		}
+		output_root := removeDir
+		if output_root == "" {
+			var err error
+			output_root, err = findManifestDir(".", args[0])
+			if err != nil {
+				pb.CaptureError(err)
+				log.Fatal(err)
+			}
+		}
+
+		manifest, err := pb.LoadManifest(output_root)
+		if err != nil {
+			pb.CaptureError(err)
+			log.Fatal(err)
+		}
+		generation, err := manifest.FindGeneration(args[0])
+		if err != nil {
+			pb.CaptureError(err)
+			log.Fatal(err)
+		}
+
+		results, err := pb.RemoveGeneration(output_root, *generation, removeForce)
+		if err != nil {
+			pb.CaptureError(err)
+			log.Fatal(err)
+		}
+		for _, result := range results {
+			fmt.Printf("%-9v %v", result.Status, result.OutputFile)
+			if result.Reason != "" {
+				fmt.Printf(" (%v)", result.Reason)
+			}
+			fmt.Println()
+		}
+
+		manifest.DeleteGeneration(generation.ID)
+		err = pb.SaveManifest(output_root, manifest)
+		if err != nil {
+			pb.CaptureError(err)
+			log.Fatal(err)
+		}
+		fmt.Printf("Removed generation %v\n", args[0])
+	},
+}
+
+// findManifestDir looks under root for the manifest recording the generation
+// id, such as the one next to a playbook when run from the repository root.
+func findManifestDir(root string, id string) (string, error) {
+	var dirs []string
+	err := filepath.WalkDir(root, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+		if entry.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if entry.Name() != pb.ManifestDir {
+			return nil
+		}
+		dir := filepath.Dir(file)
+		manifest, err := pb.LoadManifest(dir)
+		if err != nil {
+			return err
+		}
+		if _, err := manifest.FindGeneration(id); err == nil {
+			dirs = append(dirs, dir)
+		}
+		return filepath.SkipDir
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(dirs) == 0 {
+		return "", fmt.Errorf("no manifest under %s records generation %s. use --dir to give the directory holding its manifest", root, id)
+	}
+	if len(dirs) > 1 {
+		return "", fmt.Errorf("generation %s is recorded in the manifests of %s. use --dir to choose one", id, strings.Join(dirs, ", "))
+	}
+	return dirs[0], nil
+}
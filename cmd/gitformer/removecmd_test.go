@@ -0,0 +1,54 @@
+/*
+Copyright (c) 2023 Peach Pie Labs, LLC.
+*/
+
+package gitformer
+
+import (
+	"path/filepath"
+	"testing"
+
+	pb "github.com/peachpielabs/gitformer/pkg/playbook"
+)
+
+func TestFindManifestDir(t *testing.T) {
+	root := t.TempDir()
+	manifests := map[string][]string{
+		"dns":              {"20230601120000-a1b2c3"},
+		"gke/clusters":     {"20230602120000-d4e5f6", "20230603120000-abcdef"},
+		"copy":             {"20230603120000-abcdef"},
+		".git/gitformer":   {"20230601120000-a1b2c3"},
+		"dns/.gitformer/x": {"20230601120000-a1b2c3"},
+	}
+	for dir, ids := range manifests {
+		var manifest pb.Manifest
+		for _, id := range ids {
+			manifest.Generations = append(manifest.Generations, pb.Generation{ID: id})
+		}
+		if err := pb.SaveManifest(filepath.Join(root, dir), manifest); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		id      string
+		want    string
+		wantErr bool
+	}{
+		{id: "20230601120000-a1b2c3", want: "dns"},
+		{id: "20230602120000-d4e5f6", want: "gke/clusters"},
+		{id: "20230603120000-abcdef", wantErr: true},
+		{id: "20230604120000-000000", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			got, err := findManifestDir(root, tt.id)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("findManifestDir() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != filepath.Join(root, tt.want) {
+				t.Errorf("findManifestDir() = %v, want %v", got, filepath.Join(root, tt.want))
+			}
+		})
+	}
+}
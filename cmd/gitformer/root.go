@@ -5,6 +5,7 @@ Copyright (c) 2023 Peach Pie Labs, LLC.
 package gitformer
 
 import (
+	"log"
 	"os"
 
 	"github.com/peachpielabs/gitformer/pkg/playbook"
@@ -13,6 +14,8 @@ import (
 
 var version = "No version provided"
 
+var envPrefixFlag string
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:     "gitformer",
@@ -48,4 +51,13 @@ func Execute() {
 
 func init() {
 	rootCmd.CompletionOptions.DisableDefaultCmd = true
+	rootCmd.PersistentFlags().StringVar(&envPrefixFlag, "env-prefix", "", "Read answers from environment variables named with this prefix followed by the variable name in upper case, e.g. GITFORMER_")
+	// Keep secret answers out of anything logged
+	log.SetOutput(playbook.RedactingWriter(os.Stderr))
+}
+
+// addDirFlag adds the --dir flag of the commands working on the manifest of a
+// previous run. default_dir describes the directory used without the flag.
+func addDirFlag(cmd *cobra.Command, dir *string, default_dir string) {
+	cmd.Flags().StringVarP(dir, "dir", "d", "", "Directory the outputs were rendered into, holding the manifest, e.g. a checkout of the repository given to run --repo (default: "+default_dir+")")
 }
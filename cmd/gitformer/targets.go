@@ -0,0 +1,185 @@
+/*
+Copyright (c) 2023 Peach Pie Labs, LLC.
+*/
+
+package gitformer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sync"
+
+	pb "github.com/peachpielabs/gitformer/pkg/playbook"
+)
+
+const defaultTargetConcurrency = 4
+
+var (
+	targetsFlag     string
+	concurrencyFlag int
+)
+
+const (
+	targetStatusCreated = "created"
+	targetStatusSkipped = "skipped"
+	targetStatusFailed  = "failed"
+)
+
+type targetResult struct {
+	repo           string
+	status         string
+	branch         string
+	pullRequestURL string
+	reason         string
+}
+
+// runTargets applies the playbook to every repository of the targets file and
+// prints the output of each target and a report to out. It returns the number
+// of targets that failed.
+func runTargets(out io.Writer, playbook pb.Playbook, playbook_filepath string, targets pb.Targets) (int, error) {
+	// Questions answered for every target are not prompted for
+	prompted := playbook
+	prompted.Questions = nil
+	// Variables are computed once the answers of each target are known
+	prompted.Variables = nil
+	for _, question := range playbook.Questions {
+		if _, ok := targets.Answers[question.VariableName]; ok {
+			continue
+		}
+		answeredByAllTargets := true
+		for _, target := range targets.Targets {
+			if _, ok := target.Answers[question.VariableName]; !ok {
+				answeredByAllTargets = false
+			}
+		}
+		if !answeredByAllTargets {
+			prompted.Questions = append(prompted.Questions, question)
+		}
+	}
+	prompted_data, err := collectAnswers(prompted, path.Dir(playbook_filepath))
+	if err != nil {
+		return 0, err
+	}
+
+	concurrency := concurrencyFlag
+	if concurrency <= 0 {
+		concurrency = targets.Concurrency
+	}
+	if concurrency <= 0 {
+		concurrency = defaultTargetConcurrency
+	}
+
+	results := make([]targetResult, len(targets.Targets))
+	semaphore := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	// The output of each target is printed at once when it is done, so that
+	// the output of targets running at the same time does not interleave
+	var printing sync.Mutex
+	for i, target := range targets.Targets {
+		wg.Add(1)
+		go func(i int, target pb.Target) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			var output bytes.Buffer
+			results[i] = runTarget(&output, playbook, playbook_filepath, target, prompted_data, targets.Answers)
+			printing.Lock()
+			defer printing.Unlock()
+			fmt.Fprintf(out, "\n==> %v\n", target.Repo)
+			pb.RedactingWriter(out).Write(output.Bytes())
+		}(i, target)
+	}
+	wg.Wait()
+
+	failures := 0
+	fmt.Fprintln(out, "\nTargets:")
+	for _, result := range results {
+		fmt.Fprintf(out, "  %-8v %v", result.status, result.repo)
+		if result.branch != "" {
+			fmt.Fprintf(out, " branch=%v", result.branch)
+		}
+		if result.pullRequestURL != "" {
+			fmt.Fprintf(out, " pull_request=%v", result.pullRequestURL)
+		}
+		if result.reason != "" {
+			fmt.Fprintf(out, " (%v)", pb.Redact(result.reason))
+		}
+		fmt.Fprintln(out)
+		if result.status == targetStatusFailed {
+			failures++
+		}
+	}
+	return failures, nil
+}
+
+// runTarget renders the outputs into a clone of the target repository, then
+// commits them on a new branch, pushes it and opens a pull request if requested.
+// The answers are resolved once the repository is cloned, so that the options
+// of select questions are read from it. Progress is printed to out.
+func runTarget(out io.Writer, playbook pb.Playbook, playbook_filepath string, target pb.Target, prompted_data map[string]interface{}, shared map[string]interface{}) targetResult {
+	result := targetResult{repo: target.Repo}
+	fail := func(err error) targetResult {
+		result.status = targetStatusFailed
+		result.reason = err.Error()
+		return result
+	}
+
+	workspace, err := pb.CloneRepository(target.Repo)
+	if err != nil {
+		return fail(err)
+	}
+	defer os.RemoveAll(workspace.Dir)
+
+	input_data, err := pb.ResolveAnswers(out, playbook, workspace.Dir, prompted_data, shared, target)
+	if err != nil {
+		return fail(err)
+	}
+
+	generation := pb.NewGeneration(path.Base(playbook_filepath), input_data, pb.SecretNames(playbook))
+	commit, err := prepareCommit(playbook, workspace.Dir, input_data, generation, target.ForgeRepo)
+	if errors.Is(err, pb.ErrBranchExists) {
+		result.status = targetStatusSkipped
+		result.reason = err.Error()
+		return result
+	}
+	if err != nil {
+		return fail(err)
+	}
+
+	err = writeOutputs(out, playbook, path.Dir(playbook_filepath), workspace.Dir, input_data, &generation, false)
+	if err != nil {
+		return fail(err)
+	}
+	changed, err := workspace.HasChanges(generatedFiles(generation))
+	if err != nil {
+		return fail(err)
+	}
+	if !changed {
+		result.status = targetStatusSkipped
+		result.reason = "outputs are already up to date"
+		return result
+	}
+
+	_, err = commit.run(workspace.Dir, generatedFiles(generation))
+	if err != nil {
+		return fail(err)
+	}
+	err = commit.push()
+	if err != nil {
+		return fail(err)
+	}
+	result.branch = commit.branch
+	if commit.pullRequest != nil {
+		result.pullRequestURL, err = commit.openPullRequest()
+		if err != nil {
+			return fail(err)
+		}
+	}
+	result.status = targetStatusCreated
+	return result
+}
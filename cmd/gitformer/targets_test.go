@@ -0,0 +1,123 @@
+/*
+Copyright (c) 2023 Peach Pie Labs, LLC.
+*/
+
+package gitformer
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	pb "github.com/peachpielabs/gitformer/pkg/playbook"
+)
+
+// initBareRepository creates a bare repository with an initial commit to run
+// targets against.
+func initBareRepository(t *testing.T) string {
+	t.Helper()
+	source := t.TempDir()
+	bare := t.TempDir()
+	for _, args := range [][]string{
+		{"-C", source, "init", "--quiet"},
+		{"-C", source, "commit", "--quiet", "--allow-empty", "--message", "initial"},
+		{"clone", "--quiet", "--bare", source, bare},
+	} {
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	return bare
+}
+
+func TestRunTargets(t *testing.T) {
+	for _, name := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(name, "Test")
+	}
+	for _, name := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(name, "test@example.com")
+	}
+	commitFlag, pushFlag, remoteFlag = true, true, "origin"
+	defer func() { commitFlag, pushFlag, remoteFlag = false, false, "" }()
+
+	playbook_base_dir := t.TempDir()
+	playbook_filepath := filepath.Join(playbook_base_dir, "playbook.yaml")
+	err := os.WriteFile(filepath.Join(playbook_base_dir, "name.tpl"), []byte("name = \"{{.name}}\"\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Each target leaves a marker and waits for the marker of the other one,
+	// so the hook only succeeds when both targets run at the same time
+	markers := t.TempDir()
+	wait := `touch "$0/$1"; for i in $(seq 100); do [ $(ls "$0" | wc -l) -ge 2 ] && echo "$1 done" && exit 0; sleep 0.1; done; exit 1`
+	playbook := pb.Playbook{
+		Questions: []pb.Question{{VariableName: "name", InputType: "textfield", VariableType: "string"}},
+		Outputs:   []pb.Output{{TemplateFile: "name.tpl", OutputFile: "{{.name}}.tf"}},
+		Hooks:     &pb.Hooks{PostWrite: []pb.Hook{{Command: "sh", Args: []string{"-c", wait, markers, "{{.name}}"}}}},
+	}
+
+	missing := filepath.Join(t.TempDir(), "missing")
+	targets := pb.Targets{
+		Concurrency: 2,
+		Targets: []pb.Target{
+			{Repo: initBareRepository(t), Answers: map[string]interface{}{"name": "web"}},
+			{Repo: missing, Answers: map[string]interface{}{"name": "db"}},
+			{Repo: initBareRepository(t), Answers: map[string]interface{}{"name": "api"}},
+		},
+	}
+
+	var out bytes.Buffer
+	failures, err := runTargets(&out, playbook, playbook_filepath, targets)
+	if err != nil {
+		t.Fatalf("runTargets() error = %v", err)
+	}
+	if failures != 1 {
+		t.Errorf("runTargets() = %v failures, want 1\n%s", failures, out.String())
+	}
+
+	// The report lists the targets in the order of the targets file
+	report := out.String()[strings.Index(out.String(), "\nTargets:\n"):]
+	want := []*regexp.Regexp{
+		regexp.MustCompile(`^  created  ` + regexp.QuoteMeta(targets.Targets[0].Repo) + ` branch=gitformer/\S+$`),
+		regexp.MustCompile(`^  failed   ` + regexp.QuoteMeta(missing) + ` \(.+\)$`),
+		regexp.MustCompile(`^  created  ` + regexp.QuoteMeta(targets.Targets[2].Repo) + ` branch=gitformer/\S+$`),
+	}
+	lines := strings.Split(strings.TrimSpace(report), "\n")[1:]
+	if len(lines) != len(want) {
+		t.Fatalf("runTargets() reported %q", lines)
+	}
+	for i, line := range lines {
+		if !want[i].MatchString(line) {
+			t.Errorf("runTargets() reported %q, want it to match %v", line, want[i])
+		}
+	}
+
+	// The output of each target is printed in one block under its repository
+	for _, target := range targets.Targets {
+		header := "\n==> " + target.Repo + "\n"
+		start := strings.Index(out.String(), header)
+		if start < 0 {
+			t.Errorf("runTargets() printed no output for %v", target.Repo)
+			continue
+		}
+		block := out.String()[start+len(header):]
+		if end := strings.Index(block, "\n==> "); end >= 0 {
+			block = block[:end]
+		} else {
+			block = block[:strings.Index(block, "\nTargets:\n")]
+		}
+		for _, other := range []string{"web", "db", "api"} {
+			done := other + " done"
+			if other == target.Answers["name"] && target.Repo != missing && !strings.Contains(block, done) {
+				t.Errorf("runTargets() output for %v does not contain %q:\n%s", target.Repo, done, block)
+			}
+			if other != target.Answers["name"] && strings.Contains(block, done) {
+				t.Errorf("runTargets() output for %v contains %q of another target:\n%s", target.Repo, done, block)
+			}
+		}
+	}
+}
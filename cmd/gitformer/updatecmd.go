@@ -0,0 +1,107 @@
+/*
+Copyright (c) 2023 Peach Pie Labs, LLC.
+*/
+
+package gitformer
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"path"
+
+	pb "github.com/peachpielabs/gitformer/pkg/playbook"
+	"github.com/spf13/cobra"
+)
+
+var updateDir string
+
+func init() {
+	rootCmd.AddCommand(updateCmd)
+
+	addDirFlag(updateCmd, &updateDir, "the directory of the playbook file")
+}
+
+var updateCmd = &cobra.Command{
+	Use:   "update <playbook_file> [generation_id]",
+	Short: "Update generated files to the current templates",
+	Long: `Update re-renders the outputs of previous runs of a playbook with the stored answers and the current templates.
+Changes made by hand to the generated files are kept using a three-way merge. Hunks that clash are left with conflict markers.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 {
+			pb.CaptureError(errors.New("provide the filename of the playbook to update. For example:\n `gitformer update playbook.yaml`"))
+			log.Fatal("Provide the filename of the playbook to update. For example:\n `gitformer update playbook.yaml`")
+		}
+		playbook_filepath := args[0]
+		playbook_base_dir := path.Dir(playbook_filepath)
+
+		playbook, err := pb.LoadYAMLFile(playbook_filepath)
+		if err != nil {
+			pb.CaptureError(err)
+			log.Fatal(err)
+		}
+		err = pb.ValidatePlaybook(playbook, playbook_base_dir)
+		if err != nil {
+			pb.CaptureError(errors.Join(errors.New("playbook is not valid: "), err))
+			log.Fatal("playbook is not valid: ", err)
+		}
+
+		output_root := playbook_base_dir
+		if updateDir != "" {
+			output_root = updateDir
+		}
+		manifest, err := pb.LoadManifest(output_root)
+		if err != nil {
+			pb.CaptureError(err)
+			log.Fatal(err)
+		}
+
+		var generations []*pb.Generation
+		if len(args) > 1 {
+			generation, err := manifest.FindGeneration(args[1])
+			if err != nil {
+				log.Fatal(err)
+			}
+			generations = append(generations, generation)
+		} else {
+			for i := range manifest.Generations {
+				if manifest.Generations[i].Playbook == path.Base(playbook_filepath) {
+					generations = append(generations, &manifest.Generations[i])
+				}
+			}
+		}
+		if len(generations) == 0 {
+			log.Fatalf("No generations of playbook %v found in %v", playbook_filepath, pb.ManifestPath(output_root))
+		}
+
+		conflicts := 0
+		for _, generation := range generations {
+			fmt.Printf("Updating generation %v\n", generation.ID)
+			results, err := pb.UpdateGeneration(playbook_base_dir, output_root, generation)
+			if err != nil {
+				pb.CaptureError(err)
+				log.Fatal(err)
+			}
+			for _, result := range results {
+				fmt.Printf("  %-10v %v", result.Status, result.OutputFile)
+				if result.Reason != "" {
+					fmt.Printf(" (%v)", result.Reason)
+				}
+				fmt.Println()
+				for _, conflict := range result.Conflicts {
+					fmt.Printf("             conflict at line %v\n", conflict.Line)
+				}
+				conflicts += len(result.Conflicts)
+			}
+		}
+
+		err = pb.SaveManifest(output_root, manifest)
+		if err != nil {
+			pb.CaptureError(err)
+			log.Fatal(err)
+		}
+		if conflicts > 0 {
+			log.Fatalf("%v conflicting hunks found. Resolve the conflict markers in the files listed above.", conflicts)
+		}
+	},
+}
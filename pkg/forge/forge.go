@@ -0,0 +1,139 @@
+/*
+Copyright (c) 2023 Peach Pie Labs, LLC.
+*/
+
+// Package forge opens pull requests on git hosting services.
+package forge
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	GitHub = "github"
+	GitLab = "gitlab"
+	Gitea  = "gitea"
+)
+
+type PullRequest struct {
+	// Repository is the path of the repository on the forge, e.g. owner/name
+	Repository string
+	Title      string
+	Body       string
+	Head       string
+	Base       string
+}
+
+type Provider interface {
+	// CreatePullRequest opens the pull request and returns its web URL
+	CreatePullRequest(pr PullRequest) (string, error)
+}
+
+// NewProvider returns the provider for the given forge. An empty baseURL
+// selects the public instance of the forge, and an empty token is read from
+// the forge's environment variable (GITHUB_TOKEN, GITLAB_TOKEN or GITEA_TOKEN).
+// It fails when no token is found, before any request is sent.
+func NewProvider(name string, baseURL string, token string) (Provider, error) {
+	var tokenEnv string
+	switch name {
+	case GitHub:
+		tokenEnv = "GITHUB_TOKEN"
+		if baseURL == "" {
+			baseURL = "https://api.github.com"
+		}
+	case GitLab:
+		tokenEnv = "GITLAB_TOKEN"
+		if baseURL == "" {
+			baseURL = "https://gitlab.com/api/v4"
+		}
+	case Gitea:
+		tokenEnv = "GITEA_TOKEN"
+		if baseURL == "" {
+			return nil, errors.New("gitea requires the base URL of its API, e.g. https://gitea.example.com/api/v1")
+		}
+	default:
+		return nil, fmt.Errorf("unknown forge %q. supported forges are %s, %s and %s", name, GitHub, GitLab, Gitea)
+	}
+	if token == "" {
+		token = os.Getenv(tokenEnv)
+	}
+	if token == "" {
+		return nil, fmt.Errorf("no token found for %s. set %s to a token allowed to open pull requests", name, tokenEnv)
+	}
+
+	a := api{client: &http.Client{Timeout: 30 * time.Second}, baseURL: strings.TrimSuffix(baseURL, "/"), header: "Authorization"}
+	switch name {
+	case GitLab:
+		a.header = "PRIVATE-TOKEN"
+		a.token = token
+		return &gitLabProvider{api: a}, nil
+	case Gitea:
+		a.token = "token " + token
+	default:
+		a.token = "Bearer " + token
+	}
+	return &pullsProvider{api: a}, nil
+}
+
+// RepositoryFromRemote extracts the repository path (owner/name) from a git
+// remote URL such as git@github.com:owner/name.git or https://github.com/owner/name.
+func RepositoryFromRemote(remote string) (string, error) {
+	var repositoryPath string
+	if u, err := url.Parse(remote); err == nil && u.Scheme != "" && u.Host != "" {
+		repositoryPath = u.Path
+	} else if i := strings.Index(remote, ":"); i > 0 && !strings.Contains(remote[:i], "/") {
+		repositoryPath = remote[i+1:]
+	} else {
+		return "", fmt.Errorf("unable to find the repository in remote %s", remote)
+	}
+	repositoryPath = strings.TrimSuffix(strings.Trim(repositoryPath, "/"), ".git")
+	if !strings.Contains(repositoryPath, "/") {
+		return "", fmt.Errorf("unable to find the repository in remote %s", remote)
+	}
+	return repositoryPath, nil
+}
+
+type api struct {
+	client  *http.Client
+	baseURL string
+	header  string
+	token   string
+}
+
+// post sends request as JSON to the API and decodes the JSON response into response.
+func (a api) post(path string, request interface{}, response interface{}) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, a.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set(a.header, a.token)
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("POST %s failed with %s: %s", a.baseURL+path, resp.Status, strings.TrimSpace(string(respBody)))
+	}
+	return json.Unmarshal(respBody, response)
+}
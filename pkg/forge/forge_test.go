@@ -0,0 +1,138 @@
+/*
+Copyright (c) 2023 Peach Pie Labs, LLC.
+*/
+
+package forge
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCreatePullRequest(t *testing.T) {
+	tests := []struct {
+		name       string
+		forge      string
+		wantPath   string
+		wantHeader string
+		wantAuth   string
+		wantTitle  string
+		response   string
+		want       string
+	}{
+		{
+			name:       "github",
+			forge:      GitHub,
+			wantPath:   "/repos/acme/infra/pulls",
+			wantHeader: "Authorization",
+			wantAuth:   "Bearer secret",
+			wantTitle:  "title",
+			response:   `{"html_url": "https://github.com/acme/infra/pull/1"}`,
+			want:       "https://github.com/acme/infra/pull/1",
+		},
+		{
+			name:       "gitlab",
+			forge:      GitLab,
+			wantPath:   "/projects/acme%2Finfra/merge_requests",
+			wantHeader: "PRIVATE-TOKEN",
+			wantAuth:   "secret",
+			wantTitle:  "title",
+			response:   `{"web_url": "https://gitlab.com/acme/infra/-/merge_requests/1"}`,
+			want:       "https://gitlab.com/acme/infra/-/merge_requests/1",
+		},
+		{
+			name:       "gitea",
+			forge:      Gitea,
+			wantPath:   "/repos/acme/infra/pulls",
+			wantHeader: "Authorization",
+			wantAuth:   "token secret",
+			wantTitle:  "title",
+			response:   `{"html_url": "https://gitea.example.com/acme/infra/pulls/1"}`,
+			want:       "https://gitea.example.com/acme/infra/pulls/1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.EscapedPath() != tt.wantPath {
+					t.Errorf("request path = %v, want %v", r.URL.EscapedPath(), tt.wantPath)
+				}
+				if r.Header.Get(tt.wantHeader) != tt.wantAuth {
+					t.Errorf("request %v header = %v, want %v", tt.wantHeader, r.Header.Get(tt.wantHeader), tt.wantAuth)
+				}
+				var body map[string]string
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					t.Errorf("invalid request body: %v", err)
+				}
+				if body["title"] != tt.wantTitle {
+					t.Errorf("request title = %v, want %v", body["title"], tt.wantTitle)
+				}
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+
+			provider, err := NewProvider(tt.forge, server.URL, "secret")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := provider.CreatePullRequest(PullRequest{Repository: "acme/infra", Title: "title", Body: "body", Head: "gitformer/www", Base: "main"})
+			if err != nil {
+				t.Fatalf("CreatePullRequest() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("CreatePullRequest() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreatePullRequestError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(`{"message": "Validation Failed"}`))
+	}))
+	defer server.Close()
+
+	provider, err := NewProvider(GitHub, server.URL, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = provider.CreatePullRequest(PullRequest{Repository: "acme/infra"}); err == nil {
+		t.Errorf("CreatePullRequest() wanted error")
+	}
+}
+
+func TestNewProviderWithoutToken(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "")
+	if _, err := NewProvider(GitHub, "", ""); err == nil {
+		t.Errorf("NewProvider() wanted error")
+	}
+}
+
+func TestRepositoryFromRemote(t *testing.T) {
+	tests := []struct {
+		remote  string
+		want    string
+		wantErr bool
+	}{
+		{remote: "git@github.com:acme/infra.git", want: "acme/infra"},
+		{remote: "https://github.com/acme/infra", want: "acme/infra"},
+		{remote: "https://gitlab.com/acme/platform/infra.git", want: "acme/platform/infra"},
+		{remote: "ssh://git@gitea.example.com:2222/acme/infra.git", want: "acme/infra"},
+		{remote: "/srv/git/infra.git", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.remote, func(t *testing.T) {
+			got, err := RepositoryFromRemote(tt.remote)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RepositoryFromRemote() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("RepositoryFromRemote() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
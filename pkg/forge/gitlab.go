@@ -0,0 +1,29 @@
+/*
+Copyright (c) 2023 Peach Pie Labs, LLC.
+*/
+
+package forge
+
+import "net/url"
+
+type gitLabProvider struct {
+	api api
+}
+
+// CreatePullRequest opens a merge request, GitLab's name for a pull request.
+func (p *gitLabProvider) CreatePullRequest(pr PullRequest) (string, error) {
+	request := map[string]string{
+		"title":         pr.Title,
+		"description":   pr.Body,
+		"source_branch": pr.Head,
+		"target_branch": pr.Base,
+	}
+	var response struct {
+		WebURL string `json:"web_url"`
+	}
+	err := p.api.post("/projects/"+url.PathEscape(pr.Repository)+"/merge_requests", request, &response)
+	if err != nil {
+		return "", err
+	}
+	return response.WebURL, nil
+}
@@ -0,0 +1,28 @@
+/*
+Copyright (c) 2023 Peach Pie Labs, LLC.
+*/
+
+package forge
+
+// pullsProvider opens pull requests through the pulls API of GitHub, which
+// Gitea implements as well. Only the base URL and the token differ.
+type pullsProvider struct {
+	api api
+}
+
+func (p *pullsProvider) CreatePullRequest(pr PullRequest) (string, error) {
+	request := map[string]string{
+		"title": pr.Title,
+		"body":  pr.Body,
+		"head":  pr.Head,
+		"base":  pr.Base,
+	}
+	var response struct {
+		HTMLURL string `json:"html_url"`
+	}
+	err := p.api.post("/repos/"+pr.Repository+"/pulls", request, &response)
+	if err != nil {
+		return "", err
+	}
+	return response.HTMLURL, nil
+}
@@ -0,0 +1,109 @@
+/*
+Copyright (c) 2023 Peach Pie Labs, LLC.
+*/
+
+package playbook
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	CheckStatusOK       = "ok"
+	CheckStatusModified = "modified"
+	CheckStatusStale    = "stale"
+	CheckStatusMissing  = "missing"
+)
+
+type CheckResult struct {
+	OutputFile string
+	Status     string
+	Diff       string
+}
+
+// CheckGeneration renders every file of a generation with the stored answers
+// and the current templates from playbook_base_dir, and compares the result
+// with the file on disk under output_root, the directory of the manifest.
+// Files changed by hooks are compared with their recorded output as long as
+// their template renders the same. A file that differs is reported as
+// modified when it no longer matches the checksum recorded at generation
+// time, and as stale otherwise. Nothing is written to the files, and the
+// files rendered are printed to out.
+func CheckGeneration(out io.Writer, playbook_base_dir string, output_root string, generation Generation) ([]CheckResult, error) {
+	var results []CheckResult
+	for _, file := range generation.Files {
+		result := CheckResult{OutputFile: file.OutputFile, Status: CheckStatusOK}
+
+		if file.Secret {
+			status, err := checkSecretFile(output_root, file)
+			if err != nil {
+				return results, err
+			}
+			result.Status = status
+			results = append(results, result)
+			continue
+		}
+		renderedFileContents, outputFilePath, err := RenderTemplateTo(out, playbook_base_dir, output_root, file.InputData(generation.Answers), file.TemplateFile, file.OutputFile)
+		if err != nil {
+			return results, err
+		}
+		renderedFileContents, err = FormatOutput(renderedFileContents, file.Format)
+		if err != nil {
+			return results, err
+		}
+		if file.Output != "" {
+			baseContents, err := renderGeneratedFile(generation, file, file.Template)
+			if err != nil {
+				return results, err
+			}
+			if baseContents == renderedFileContents {
+				renderedFileContents = file.Output
+			}
+		}
+		currentContents, err := os.ReadFile(outputFilePath)
+		if os.IsNotExist(err) {
+			result.Status = CheckStatusMissing
+			results = append(results, result)
+			continue
+		}
+		if err != nil {
+			return results, err
+		}
+
+		if file.Mode == OutputModeAppend {
+			// Appended outputs only own a block of the file
+			if !strings.Contains(string(currentContents), renderedFileContents) {
+				result.Status = CheckStatusModified
+			}
+		} else if string(currentContents) != renderedFileContents {
+			if Checksum(string(currentContents)) != file.Checksum {
+				result.Status = CheckStatusModified
+			} else {
+				result.Status = CheckStatusStale
+			}
+			result.Diff = UnifiedDiff(filepath.ToSlash(filepath.Join("expected", file.OutputFile)), filepath.ToSlash(filepath.Join("actual", file.OutputFile)), renderedFileContents, string(currentContents))
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// checkSecretFile checks a file rendered with secret answers, which are not in
+// the manifest, so the file cannot be rendered again. It is reported as
+// modified when it no longer matches its checksum, and is never diffed.
+func checkSecretFile(output_root string, file GeneratedFile) (string, error) {
+	currentContents, err := os.ReadFile(filepath.Join(output_root, file.OutputFile))
+	if os.IsNotExist(err) {
+		return CheckStatusMissing, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if file.Mode != OutputModeAppend && Checksum(string(currentContents)) != file.Checksum {
+		return CheckStatusModified, nil
+	}
+	return CheckStatusOK, nil
+}
@@ -0,0 +1,129 @@
+/*
+Copyright (c) 2023 Peach Pie Labs, LLC.
+*/
+
+package playbook
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckGeneration(t *testing.T) {
+	// The outputs were rendered into another repository than the playbook's
+	playbook_base_dir := t.TempDir()
+	output_root := t.TempDir()
+	template_source := "name = \"{{.name}}\"\n"
+	err := os.WriteFile(filepath.Join(playbook_base_dir, "file.tpl"), []byte(template_source), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"ok.tf":       "name = \"web\"\n",
+		"modified.tf": "name = \"web\"\n# edited\n",
+		"stale.tf":    "name = web\n",
+	}
+	for name, contents := range files {
+		err = os.WriteFile(filepath.Join(output_root, name), []byte(contents), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	generation := NewGeneration("playbook.yaml", map[string]interface{}{"name": "web"}, nil)
+	generation.Files = []GeneratedFile{
+		{TemplateFile: "file.tpl", OutputFile: "ok.tf", Checksum: Checksum("name = \"web\"\n")},
+		{TemplateFile: "file.tpl", OutputFile: "modified.tf", Checksum: Checksum("name = \"web\"\n")},
+		{TemplateFile: "file.tpl", OutputFile: "stale.tf", Checksum: Checksum("name = web\n")},
+		{TemplateFile: "file.tpl", OutputFile: "missing.tf", Checksum: Checksum("name = \"web\"\n")},
+	}
+
+	results, err := CheckGeneration(io.Discard, playbook_base_dir, output_root, generation)
+	if err != nil {
+		t.Fatalf("CheckGeneration() error = %v", err)
+	}
+	want := []string{CheckStatusOK, CheckStatusModified, CheckStatusStale, CheckStatusMissing}
+	for i, result := range results {
+		if result.Status != want[i] {
+			t.Errorf("CheckGeneration() %v status = %v, want %v", result.OutputFile, result.Status, want[i])
+		}
+		if (result.Diff != "") != (want[i] == CheckStatusModified || want[i] == CheckStatusStale) {
+			t.Errorf("CheckGeneration() %v unexpected diff %q", result.OutputFile, result.Diff)
+		}
+	}
+}
+
+func TestCheckGenerationForEach(t *testing.T) {
+	playbook_base_dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(playbook_base_dir, "env.tpl"), []byte("{{.index}}: {{.name}}-{{.item}}\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(playbook_base_dir, "prod.tf"), []byte("1: web-prod\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	index := 1
+	generation := NewGeneration("playbook.yaml", map[string]interface{}{"name": "web", "environments": "dev, prod"}, nil)
+	generation.Files = []GeneratedFile{
+		{TemplateFile: "env.tpl", OutputFile: "prod.tf", Checksum: Checksum("1: web-prod\n"), Item: "prod", Index: &index},
+	}
+
+	results, err := CheckGeneration(io.Discard, playbook_base_dir, playbook_base_dir, generation)
+	if err != nil {
+		t.Fatalf("CheckGeneration() error = %v", err)
+	}
+	if len(results) != 1 || results[0].Status != CheckStatusOK {
+		t.Errorf("CheckGeneration() = %+v, want %v", results, CheckStatusOK)
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	got := UnifiedDiff("a", "b", "one\ntwo\nthree\n", "one\n2\nthree\nfour\n")
+	want := "--- a\n+++ b\n@@ -1,3 +1,4 @@\n one\n-two\n+2\n three\n+four\n"
+	if got != want {
+		t.Errorf("UnifiedDiff() = %q, want %q", got, want)
+	}
+}
+
+func TestCheckGenerationHookOutput(t *testing.T) {
+	playbook_base_dir := t.TempDir()
+	template_source := "name=\"{{.name}}\"\n"
+	err := os.WriteFile(filepath.Join(playbook_base_dir, "file.tpl"), []byte(template_source), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A formatting hook changed the rendered template when it was written
+	hooked := "name = \"web\"\n"
+	err = os.WriteFile(filepath.Join(playbook_base_dir, "out.tf"), []byte(hooked), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	generation := NewGeneration("playbook.yaml", map[string]interface{}{"name": "web"}, nil)
+	generation.Files = []GeneratedFile{
+		{TemplateFile: "file.tpl", OutputFile: "out.tf", Template: template_source, Output: hooked, Checksum: Checksum(hooked)},
+	}
+
+	results, err := CheckGeneration(io.Discard, playbook_base_dir, playbook_base_dir, generation)
+	if err != nil {
+		t.Fatalf("CheckGeneration() error = %v", err)
+	}
+	if results[0].Status != CheckStatusOK {
+		t.Errorf("CheckGeneration() status = %v with diff %q, want %v", results[0].Status, results[0].Diff, CheckStatusOK)
+	}
+
+	err = os.WriteFile(filepath.Join(playbook_base_dir, "file.tpl"), []byte("name=\"{{.name}}\"\nsize=2\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err = CheckGeneration(io.Discard, playbook_base_dir, playbook_base_dir, generation)
+	if err != nil {
+		t.Fatalf("CheckGeneration() error = %v", err)
+	}
+	if results[0].Status != CheckStatusStale {
+		t.Errorf("CheckGeneration() status = %v, want %v", results[0].Status, CheckStatusStale)
+	}
+}
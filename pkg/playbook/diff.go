@@ -0,0 +1,173 @@
+/*
+Copyright (c) 2023 Peach Pie Labs, LLC.
+*/
+
+package playbook
+
+import (
+	"fmt"
+	"strings"
+)
+
+// splitLines splits content into lines, keeping the line endings so that
+// joining the result gives back the original content.
+func splitLines(content string) []string {
+	lines := strings.SplitAfter(content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// matchLines computes a longest common subsequence of lines between a and b.
+// The returned slice maps every line index of a to the index of its matching
+// line in b, or -1 when the line is not part of the common subsequence.
+func matchLines(a, b []string) []int {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	matches := make([]int, len(a))
+	i, j := 0, 0
+	for i < len(a) {
+		if j < len(b) && a[i] == b[j] {
+			matches[i] = j
+			i++
+			j++
+		} else if j < len(b) && lcs[i][j+1] > lcs[i+1][j] {
+			j++
+		} else {
+			matches[i] = -1
+			i++
+		}
+	}
+	return matches
+}
+
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+const diffContextLines = 3
+
+type diffLine struct {
+	kind byte
+	text string
+}
+
+// UnifiedDiff returns the differences between from and to in unified diff
+// format, or an empty string when they are equal.
+func UnifiedDiff(fromName, toName, from, to string) string {
+	if from == to {
+		return ""
+	}
+	fromLines := splitLines(from)
+	toLines := splitLines(to)
+	matches := matchLines(fromLines, toLines)
+
+	var lines []diffLine
+	j := 0
+	for i, line := range fromLines {
+		if matches[i] < 0 {
+			lines = append(lines, diffLine{'-', line})
+			continue
+		}
+		for ; j < matches[i]; j++ {
+			lines = append(lines, diffLine{'+', toLines[j]})
+		}
+		lines = append(lines, diffLine{' ', line})
+		j++
+	}
+	for ; j < len(toLines); j++ {
+		lines = append(lines, diffLine{'+', toLines[j]})
+	}
+
+	var diff strings.Builder
+	diff.WriteString("--- " + fromName + "\n")
+	diff.WriteString("+++ " + toName + "\n")
+	fromLine, toLine := 1, 1
+	for start := 0; start < len(lines); {
+		if lines[start].kind == ' ' {
+			start++
+			fromLine++
+			toLine++
+			continue
+		}
+
+		// Extend the hunk while changes are close enough to share context
+		end := start
+		for k := start; k < len(lines) && k <= end+2*diffContextLines; k++ {
+			if lines[k].kind != ' ' {
+				end = k
+			}
+		}
+		hunkStart := start - diffContextLines
+		if hunkStart < 0 {
+			hunkStart = 0
+		}
+		hunkEnd := end + diffContextLines + 1
+		if hunkEnd > len(lines) {
+			hunkEnd = len(lines)
+		}
+
+		hunkFromLine, hunkToLine := fromLine-(start-hunkStart), toLine-(start-hunkStart)
+		fromCount, toCount := 0, 0
+		var hunk strings.Builder
+		for _, line := range lines[hunkStart:hunkEnd] {
+			if line.kind != '+' {
+				fromCount++
+			}
+			if line.kind != '-' {
+				toCount++
+			}
+			hunk.WriteByte(line.kind)
+			hunk.WriteString(line.text)
+			if !strings.HasSuffix(line.text, "\n") {
+				hunk.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		diff.WriteString(fmt.Sprintf("@@ -%s +%s @@\n", hunkRange(hunkFromLine, fromCount), hunkRange(hunkToLine, toCount)))
+		diff.WriteString(hunk.String())
+
+		for _, line := range lines[start:hunkEnd] {
+			if line.kind != '+' {
+				fromLine++
+			}
+			if line.kind != '-' {
+				toLine++
+			}
+		}
+		start = hunkEnd
+	}
+	return diff.String()
+}
+
+func hunkRange(line, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", line-1)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", line)
+	}
+	return fmt.Sprintf("%d,%d", line, count)
+}
@@ -0,0 +1,42 @@
+/*
+Copyright (c) 2023 Peach Pie Labs, LLC.
+*/
+
+package playbook
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func validateEnv(question Question) error {
+	if question.Env != "" && !envNamePattern.MatchString(question.Env) {
+		return fmt.Errorf("invalid env %q for question %s. environment variable names may only contain letters, digits and underscores", question.Env, question.VariableName)
+	}
+	return nil
+}
+
+// EnvAnswer looks up the answer to a question in the environment, first in
+// the variable named by its env, then in prefix followed by its variable name
+// in upper case, e.g. INPUT_CLUSTER_NAME for the prefix INPUT_. Variables set
+// to an empty string are ignored, like the inputs of a GitHub Actions workflow
+// that were not given. It returns the answer and the variable it was read from.
+func EnvAnswer(question Question, prefix string) (string, string, bool) {
+	var names []string
+	if question.Env != "" {
+		names = append(names, question.Env)
+	}
+	if prefix != "" {
+		names = append(names, prefix+strings.ToUpper(question.VariableName))
+	}
+	for _, name := range names {
+		if value := os.Getenv(name); value != "" {
+			return value, name, true
+		}
+	}
+	return "", "", false
+}
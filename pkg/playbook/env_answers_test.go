@@ -0,0 +1,37 @@
+/*
+Copyright (c) 2023 Peach Pie Labs, LLC.
+*/
+
+package playbook
+
+import "testing"
+
+func TestEnvAnswer(t *testing.T) {
+	t.Setenv("TF_VAR_cluster_name", "web")
+	t.Setenv("INPUT_CLUSTER_NAME", "api")
+	t.Setenv("INPUT_REGION", "us-east1")
+	t.Setenv("INPUT_ZONE", "")
+
+	tests := []struct {
+		name      string
+		question  Question
+		prefix    string
+		want      string
+		wantName  string
+		wantFound bool
+	}{
+		{name: "env", question: Question{VariableName: "cluster_name", Env: "TF_VAR_cluster_name"}, prefix: "INPUT_", want: "web", wantName: "TF_VAR_cluster_name", wantFound: true},
+		{name: "prefix", question: Question{VariableName: "cluster_name"}, prefix: "INPUT_", want: "api", wantName: "INPUT_CLUSTER_NAME", wantFound: true},
+		{name: "env_not_set", question: Question{VariableName: "region", Env: "TF_VAR_region"}, prefix: "INPUT_", want: "us-east1", wantName: "INPUT_REGION", wantFound: true},
+		{name: "no_prefix", question: Question{VariableName: "region"}},
+		{name: "empty", question: Question{VariableName: "zone"}, prefix: "INPUT_"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, name, found := EnvAnswer(tt.question, tt.prefix)
+			if got != tt.want || name != tt.wantName || found != tt.wantFound {
+				t.Errorf("EnvAnswer() = %q, %q, %v, want %q, %q, %v", got, name, found, tt.want, tt.wantName, tt.wantFound)
+			}
+		})
+	}
+}
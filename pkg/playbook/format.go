@@ -0,0 +1,151 @@
+/*
+Copyright (c) 2023 Peach Pie Labs, LLC.
+*/
+
+package playbook
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/format"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	FormatGo                     = "go"
+	FormatJSON                   = "json"
+	FormatYAML                   = "yaml"
+	FormatTrimTrailingWhitespace = "trim-trailing-whitespace"
+	FormatFinalNewline           = "final-newline"
+	FormatLF                     = "lf"
+	FormatCRLF                   = "crlf"
+)
+
+var formatters = map[string]func(string) (string, error){
+	FormatGo:                     formatGo,
+	FormatJSON:                   formatJSON,
+	FormatYAML:                   formatYAML,
+	FormatTrimTrailingWhitespace: trimTrailingWhitespace,
+	FormatFinalNewline:           ensureFinalNewline,
+	FormatLF:                     func(contents string) (string, error) { return toLF(contents), nil },
+	FormatCRLF:                   func(contents string) (string, error) { return strings.ReplaceAll(toLF(contents), "\n", "\r\n"), nil },
+}
+
+func validateFormats(formats []string) error {
+	for _, name := range formats {
+		if _, ok := formatters[name]; !ok {
+			return fmt.Errorf("invalid format %q. valid formats are %s, %s, %s, %s, %s, %s and %s", name, FormatGo, FormatJSON, FormatYAML, FormatTrimTrailingWhitespace, FormatFinalNewline, FormatLF, FormatCRLF)
+		}
+	}
+	return nil
+}
+
+// FormatOutput applies the formatters to rendered contents in the given order.
+func FormatOutput(contents string, formats []string) (string, error) {
+	for _, name := range formats {
+		formatter, ok := formatters[name]
+		if !ok {
+			return "", fmt.Errorf("invalid format %q", name)
+		}
+		var err error
+		contents, err = formatter(contents)
+		if err != nil {
+			return "", errors.Join(fmt.Errorf("unable to format the output as %s", name), err)
+		}
+	}
+	return contents, nil
+}
+
+func formatGo(contents string) (string, error) {
+	formatted, err := format.Source([]byte(contents))
+	if err != nil {
+		return "", err
+	}
+	return string(formatted), nil
+}
+
+func formatJSON(contents string) (string, error) {
+	var formatted bytes.Buffer
+	err := json.Indent(&formatted, []byte(strings.TrimSpace(contents)), "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return formatted.String() + "\n", nil
+}
+
+// yamlDocument keeps the order of mapping keys when a document is decoded and
+// encoded again.
+type yamlDocument struct {
+	value interface{}
+}
+
+func (d *yamlDocument) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var mapping yaml.MapSlice
+	if err := unmarshal(&mapping); err == nil {
+		d.value = mapping
+		return nil
+	}
+	return unmarshal(&d.value)
+}
+
+func (d yamlDocument) MarshalYAML() (interface{}, error) {
+	return d.value, nil
+}
+
+// formatYAML normalizes the indentation and quoting of every document. Comments are not kept.
+func formatYAML(contents string) (string, error) {
+	decoder := yaml.NewDecoder(strings.NewReader(contents))
+	var documents []string
+	for {
+		var document yamlDocument
+		err := decoder.Decode(&document)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		if document.value == nil {
+			continue
+		}
+		formatted, err := yaml.Marshal(document)
+		if err != nil {
+			return "", err
+		}
+		documents = append(documents, string(formatted))
+	}
+	return strings.Join(documents, "---\n"), nil
+}
+
+func trimTrailingWhitespace(contents string) (string, error) {
+	lines := splitLines(contents)
+	for i, line := range lines {
+		ending := ""
+		if strings.HasSuffix(line, "\r\n") {
+			ending = "\r\n"
+		} else if strings.HasSuffix(line, "\n") {
+			ending = "\n"
+		}
+		lines[i] = strings.TrimRight(strings.TrimSuffix(line, ending), " \t") + ending
+	}
+	return strings.Join(lines, ""), nil
+}
+
+func ensureFinalNewline(contents string) (string, error) {
+	if contents == "" || strings.HasSuffix(contents, "\n") {
+		return contents, nil
+	}
+	if strings.Contains(contents, "\r\n") {
+		return contents + "\r\n", nil
+	}
+	return contents + "\n", nil
+}
+
+func toLF(contents string) string {
+	return strings.ReplaceAll(contents, "\r\n", "\n")
+}
@@ -0,0 +1,79 @@
+/*
+Copyright (c) 2023 Peach Pie Labs, LLC.
+*/
+
+package playbook
+
+import (
+	"testing"
+)
+
+func TestFormatOutput(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		formats  []string
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "go",
+			contents: "package main\nfunc main(){\nx:=1\n_ = x}\n",
+			formats:  []string{FormatGo},
+			want:     "package main\n\nfunc main() {\n\tx := 1\n\t_ = x\n}\n",
+		},
+		{
+			name:     "invalid_go",
+			contents: "package main\nfunc main() {\n",
+			formats:  []string{FormatGo},
+			wantErr:  true,
+		},
+		{
+			name:     "json",
+			contents: `{"name": "www", "records": [1,2]}`,
+			formats:  []string{FormatJSON},
+			want:     "{\n  \"name\": \"www\",\n  \"records\": [\n    1,\n    2\n  ]\n}\n",
+		},
+		{
+			name:     "invalid_json",
+			contents: `{"name": "www",}`,
+			formats:  []string{FormatJSON},
+			wantErr:  true,
+		},
+		{
+			name:     "yaml_keeps_key_order",
+			contents: "name:   www\nspec:\n    zone: example.com\n    ttl: 300\n---\n- a\n-   b\n",
+			formats:  []string{FormatYAML},
+			want:     "name: www\nspec:\n  zone: example.com\n  ttl: 300\n---\n- a\n- b\n",
+		},
+		{
+			name:     "whitespace_and_line_endings",
+			contents: "a  \r\nb\t\r\nc",
+			formats:  []string{FormatTrimTrailingWhitespace, FormatLF, FormatFinalNewline},
+			want:     "a\nb\nc\n",
+		},
+		{
+			name:     "crlf",
+			contents: "a\nb\n",
+			formats:  []string{FormatCRLF},
+			want:     "a\r\nb\r\n",
+		},
+		{
+			name:     "unknown_format",
+			contents: "a",
+			formats:  []string{"hcl"},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FormatOutput(tt.contents, tt.formats)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FormatOutput() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("FormatOutput() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+/*
+Copyright (c) 2023 Peach Pie Labs, LLC.
+*/
+
+package playbook
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+const (
+	DefaultBranchTemplate           = "gitformer/{{.generation_id}}"
+	DefaultCommitMessageTemplate    = "Run playbook {{.playbook_name}}"
+	DefaultPullRequestTitleTemplate = "Run playbook {{.playbook_name}}"
+	DefaultPullRequestBodyTemplate  = `Generated by gitformer from playbook {{.playbook_name}} (generation {{.generation_id}}).
+
+| Variable | Answer |
+| -------- | ------ |
+{{- range $name, $answer := .answers}}
+| {{$name}} | {{$answer}} |
+{{- end}}
+`
+)
+
+var ErrBranchExists = errors.New("branch already exists")
+
+var gitAuthorPattern = regexp.MustCompile(`^([^<>]+) <([^<>]+)>$`)
+
+type GitOptions struct {
+	Branch        string `yaml:"branch,omitempty"`
+	CommitMessage string `yaml:"commitMessage,omitempty"`
+	Author        string `yaml:"author,omitempty"`
+	// PullRequest holds the templates used when opening a pull request with --pull-request
+	PullRequest *PullRequestOptions `yaml:"pullRequest,omitempty"`
+}
+
+type PullRequestOptions struct {
+	Title string `yaml:"title,omitempty"`
+	Body  string `yaml:"body,omitempty"`
+}
+
+type GitRepository struct {
+	Dir string
+}
+
+// OpenGitRepository returns the git repository containing dir.
+func OpenGitRepository(dir string) (GitRepository, error) {
+	repository := GitRepository{Dir: dir}
+	top, err := repository.git(nil, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return GitRepository{}, fmt.Errorf("%s is not inside a git repository: %w", dir, err)
+	}
+	return GitRepository{Dir: top}, nil
+}
+
+// CloneRepository clones source, a URL or the path of a local repository, into
+// a new temporary directory. The caller is responsible for removing it.
+func CloneRepository(source string) (GitRepository, error) {
+	dir, err := os.MkdirTemp("", "gitformer-")
+	if err != nil {
+		return GitRepository{}, err
+	}
+	repository := GitRepository{Dir: dir}
+	_, err = repository.git(nil, "clone", "--quiet", source, ".")
+	if err != nil {
+		os.RemoveAll(dir)
+		return GitRepository{}, err
+	}
+	return repository, nil
+}
+
+func (r GitRepository) git(env []string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", r.Dir}, args...)...)
+	cmd.Env = append(os.Environ(), env...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+func (r GitRepository) HasStagedChanges() (bool, error) {
+	_, err := r.git(nil, "diff", "--cached", "--quiet")
+	if err == nil {
+		return false, nil
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return true, nil
+	}
+	return false, err
+}
+
+// HasChanges reports whether any of the given paths differ from HEAD.
+func (r GitRepository) HasChanges(paths []string) (bool, error) {
+	status, err := r.git(nil, append([]string{"status", "--porcelain", "--"}, paths...)...)
+	if err != nil {
+		return false, err
+	}
+	return status != "", nil
+}
+
+// ValidateBranch checks that branch is a valid branch name that does not
+// exist yet, locally or on any remote.
+func (r GitRepository) ValidateBranch(branch string) error {
+	if _, err := r.git(nil, "check-ref-format", "--branch", branch); err != nil {
+		return fmt.Errorf("invalid branch name %q", branch)
+	}
+	refs, err := r.git(nil, "for-each-ref", "--format=%(refname)", "refs/heads/"+branch, "refs/remotes/*/"+branch)
+	if err != nil {
+		return err
+	}
+	if refs != "" {
+		return fmt.Errorf("branch %s: %w", branch, ErrBranchExists)
+	}
+	return nil
+}
+
+func (r GitRepository) CurrentBranch() (string, error) {
+	return r.git(nil, "rev-parse", "--abbrev-ref", "HEAD")
+}
+
+func (r GitRepository) RemoteURL(remote string) (string, error) {
+	return r.git(nil, "remote", "get-url", remote)
+}
+
+func (r GitRepository) Push(remote string, branch string) error {
+	_, err := r.git(nil, "push", "--set-upstream", remote, branch)
+	return err
+}
+
+func (r GitRepository) CreateBranch(branch string) error {
+	_, err := r.git(nil, "checkout", "-b", branch)
+	return err
+}
+
+// Commit stages and commits exactly the given paths, leaving anything else in
+// the index out of the commit. It returns the hash of the new commit.
+func (r GitRepository) Commit(paths []string, message string, author string) (string, error) {
+	_, err := r.git(nil, append([]string{"add", "--"}, paths...)...)
+	if err != nil {
+		return "", err
+	}
+
+	args := []string{"commit", "--message", message}
+	var env []string
+	if author != "" {
+		name, email, err := ParseGitAuthor(author)
+		if err != nil {
+			return "", err
+		}
+		args = append(args, "--author", author)
+		env = append(env, "GIT_COMMITTER_NAME="+name, "GIT_COMMITTER_EMAIL="+email)
+	}
+	args = append(args, "--")
+	_, err = r.git(env, append(args, paths...)...)
+	if err != nil {
+		return "", err
+	}
+	return r.git(nil, "rev-parse", "HEAD")
+}
+
+// ParseGitAuthor splits an author in the "Name <email>" format.
+func ParseGitAuthor(author string) (string, string, error) {
+	matches := gitAuthorPattern.FindStringSubmatch(author)
+	if matches == nil {
+		return "", "", fmt.Errorf("invalid commit author %q. use the format \"Name <email>\"", author)
+	}
+	return matches[1], matches[2], nil
+}
@@ -0,0 +1,106 @@
+/*
+Copyright (c) 2023 Peach Pie Labs, LLC.
+*/
+
+package playbook
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func initGitRepository(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"config", "user.name", "Test"},
+		{"config", "user.email", "test@example.com"},
+		{"commit", "--quiet", "--allow-empty", "--message", "initial"},
+	} {
+		if out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	return dir
+}
+
+func TestGitRepositoryCommit(t *testing.T) {
+	dir := initGitRepository(t)
+	repository, err := OpenGitRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"generated.tf", "unrelated.tf"} {
+		if err = os.WriteFile(filepath.Join(dir, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err = repository.git(nil, "add", "unrelated.tf"); err != nil {
+		t.Fatal(err)
+	}
+	dirty, err := repository.HasStagedChanges()
+	if err != nil || !dirty {
+		t.Fatalf("HasStagedChanges() = %v, %v, want true", dirty, err)
+	}
+
+	if err = repository.ValidateBranch("gitformer/new..branch"); err == nil {
+		t.Errorf("ValidateBranch() wanted error for an invalid branch name")
+	}
+	if err = repository.ValidateBranch("gitformer/www"); err != nil {
+		t.Fatalf("ValidateBranch() error = %v", err)
+	}
+	if err = repository.CreateBranch("gitformer/www"); err != nil {
+		t.Fatal(err)
+	}
+	if err = repository.ValidateBranch("gitformer/www"); err == nil {
+		t.Errorf("ValidateBranch() wanted error for an existing branch")
+	}
+
+	_, err = repository.Commit([]string{filepath.Join(dir, "generated.tf")}, "Add generated.tf", "Gitformer <gitformer@example.com>")
+	if err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	files, err := repository.git(nil, "show", "--name-only", "--format=%an", "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files != "Gitformer\n\ngenerated.tf" {
+		t.Errorf("Commit() committed %q", files)
+	}
+}
+
+func TestCloneRepositoryAndPush(t *testing.T) {
+	source := initGitRepository(t)
+	remote := t.TempDir()
+	if out, err := exec.Command("git", "clone", "--quiet", "--bare", source, remote).CombinedOutput(); err != nil {
+		t.Fatalf("git clone: %v: %s", err, out)
+	}
+
+	workspace, err := CloneRepository(remote)
+	if err != nil {
+		t.Fatalf("CloneRepository() error = %v", err)
+	}
+	defer os.RemoveAll(workspace.Dir)
+
+	if err = os.WriteFile(filepath.Join(workspace.Dir, "generated.tf"), []byte("generated"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err = workspace.CreateBranch("gitformer/www"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = workspace.Commit([]string{filepath.Join(workspace.Dir, "generated.tf")}, "Add generated.tf", "Gitformer <gitformer@example.com>"); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	if err = workspace.Push("origin", "gitformer/www"); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+
+	remoteRepository := GitRepository{Dir: remote}
+	if _, err = remoteRepository.git(nil, "rev-parse", "--verify", "refs/heads/gitformer/www"); err != nil {
+		t.Errorf("Push() did not create the branch on the remote: %v", err)
+	}
+}
@@ -0,0 +1,183 @@
+/*
+Copyright (c) 2023 Peach Pie Labs, LLC.
+*/
+
+package playbook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	HookStagePreRender  = "preRender"
+	HookStagePostRender = "postRender"
+	HookStagePostWrite  = "postWrite"
+)
+
+const defaultHookTimeout = 5 * time.Minute
+
+// Hooks run commands around the rendering of the outputs, e.g. to format or
+// validate them. preRender hooks run once the questions are answered,
+// postRender hooks once every output is rendered, on a staged copy of the
+// outputs, and postWrite hooks once the outputs are written. Written outputs
+// are rolled back when a postWrite hook fails.
+type Hooks struct {
+	PreRender  []Hook `yaml:"preRender,omitempty"`
+	PostRender []Hook `yaml:"postRender,omitempty"`
+	PostWrite  []Hook `yaml:"postWrite,omitempty"`
+}
+
+type Hook struct {
+	// Command and Args are templates rendered with the answers, along with
+	// output_root and output_files
+	Command string   `yaml:"command"`
+	Args    []string `yaml:"args,omitempty"`
+	// Dir is the working directory relative to the output root
+	Dir             string `yaml:"dir,omitempty"`
+	Timeout         string `yaml:"timeout,omitempty"`
+	ContinueOnError bool   `yaml:"continueOnError,omitempty"`
+}
+
+func validateHooks(hooks *Hooks) error {
+	if hooks == nil {
+		return nil
+	}
+	stages := []struct {
+		name  string
+		hooks []Hook
+	}{{HookStagePreRender, hooks.PreRender}, {HookStagePostRender, hooks.PostRender}, {HookStagePostWrite, hooks.PostWrite}}
+	for _, stage := range stages {
+		for _, hook := range stage.hooks {
+			if hook.Command == "" {
+				return fmt.Errorf("no command given in a %s hook. every hook must have a command", stage.name)
+			}
+			if hook.Timeout != "" {
+				if _, err := time.ParseDuration(hook.Timeout); err != nil {
+					return fmt.Errorf("invalid timeout %q in %s hook %s", hook.Timeout, stage.name, hook.Command)
+				}
+			}
+			for _, text := range append([]string{hook.Command, hook.Dir}, hook.Args...) {
+				if err := parseText(text); err != nil {
+					return errors.Join(fmt.Errorf("invalid template in %s hook %s", stage.name, hook.Command), err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// StageOutputs writes rendered outputs, keyed by their path relative to the
+// output root, into a new temporary directory for postRender hooks to format
+// and check before anything is written. The caller removes the directory.
+func StageOutputs(outputs map[string]string) (string, error) {
+	staging_dir, err := os.MkdirTemp("", "gitformer-render-")
+	if err != nil {
+		return "", err
+	}
+	for output_file, contents := range outputs {
+		staged_path := filepath.Join(staging_dir, output_file)
+		err := os.MkdirAll(filepath.Dir(staged_path), 0755)
+		if err == nil {
+			err = os.WriteFile(staged_path, []byte(contents), 0644)
+		}
+		if err != nil {
+			os.RemoveAll(staging_dir)
+			return "", err
+		}
+	}
+	return staging_dir, nil
+}
+
+// RunHooks runs the hooks of a stage in order. The answers are passed to the
+// commands as GITFORMER_VAR_<VARIABLE_NAME> environment variables, and the
+// output files, relative to output_root, as GITFORMER_OUTPUT_FILES with one
+// file per line. The hooks and the output of their commands are printed to out.
+func RunHooks(out io.Writer, stage string, hooks []Hook, output_root string, input_data map[string]interface{}, output_files []string) error {
+	template_data := map[string]interface{}{
+		"output_root":  output_root,
+		"output_files": output_files,
+	}
+	for key, value := range input_data {
+		template_data[key] = value
+	}
+
+	env := append(os.Environ(), answerEnv(input_data)...)
+	env = append(env, "GITFORMER_OUTPUT_ROOT="+output_root, "GITFORMER_OUTPUT_FILES="+strings.Join(output_files, "\n"))
+
+	for _, hook := range hooks {
+		err := runHook(out, hook, output_root, template_data, env)
+		if err != nil {
+			err = fmt.Errorf("%s hook failed: %w", stage, err)
+			if !hook.ContinueOnError {
+				return err
+			}
+			fmt.Fprintln(out, err)
+		}
+	}
+	return nil
+}
+
+// answerEnv passes the answers to commands as GITFORMER_VAR_<VARIABLE_NAME>
+// environment variables.
+func answerEnv(input_data map[string]interface{}) []string {
+	var env []string
+	for key, value := range input_data {
+		env = append(env, "GITFORMER_VAR_"+strings.ToUpper(key)+"="+fmt.Sprint(value))
+	}
+	return env
+}
+
+func runHook(out io.Writer, hook Hook, output_root string, template_data map[string]interface{}, env []string) error {
+	command, err := RenderText(hook.Command, template_data)
+	if err != nil {
+		return err
+	}
+	var args []string
+	for _, arg := range hook.Args {
+		renderedArg, err := RenderText(arg, template_data)
+		if err != nil {
+			return err
+		}
+		args = append(args, renderedArg)
+	}
+	dir, err := RenderText(hook.Dir, template_data)
+	if err != nil {
+		return err
+	}
+	timeout := defaultHookTimeout
+	if hook.Timeout != "" {
+		timeout, err = time.ParseDuration(hook.Timeout)
+		if err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, command, args...)
+	cmd.Dir = filepath.Join(output_root, dir)
+	cmd.Env = env
+	// Hooks may print the answers they are given, secret ones included
+	output := &lineRedactingWriter{w: out}
+	cmd.Stdout = output
+	cmd.Stderr = output
+
+	fmt.Fprint(out, Redact(fmt.Sprintf("running hook %v %v\n", command, strings.Join(args, " "))))
+	err = cmd.Run()
+	output.Flush()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("%s timed out after %v", command, timeout)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", command, err)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+/*
+Copyright (c) 2023 Peach Pie Labs, LLC.
+*/
+
+package playbook
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunHooks(t *testing.T) {
+	output_root := t.TempDir()
+	input_data := map[string]interface{}{"subdomain_name": "www"}
+
+	hooks := []Hook{
+		{
+			Command: "sh",
+			Args:    []string{"-c", "echo \"$GITFORMER_VAR_SUBDOMAIN_NAME {{.subdomain_name}} $GITFORMER_OUTPUT_FILES\" > hook.txt"},
+		},
+	}
+	err := RunHooks(io.Discard, HookStagePostWrite, hooks, output_root, input_data, []string{"terraform/www.tf"})
+	if err != nil {
+		t.Fatalf("RunHooks() error = %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join(output_root, "hook.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "www www terraform/www.tf\n" {
+		t.Errorf("RunHooks() hook wrote %q", got)
+	}
+
+	failing := []Hook{{Command: "false"}}
+	if err = RunHooks(io.Discard, HookStagePostWrite, failing, output_root, input_data, nil); err == nil {
+		t.Errorf("RunHooks() wanted error for a failing hook")
+	}
+	failing[0].ContinueOnError = true
+	if err = RunHooks(io.Discard, HookStagePostWrite, failing, output_root, input_data, nil); err != nil {
+		t.Errorf("RunHooks() error = %v with continueOnError", err)
+	}
+
+	slow := []Hook{{Command: "sleep", Args: []string{"5"}, Timeout: "100ms"}}
+	if err = RunHooks(io.Discard, HookStagePreRender, slow, output_root, input_data, nil); err == nil {
+		t.Errorf("RunHooks() wanted error for a hook exceeding its timeout")
+	}
+}
+
+func TestRunHooksRedactsSecrets(t *testing.T) {
+	RegisterSecret("hook-secret-token")
+	input_data := map[string]interface{}{"api_token": "hook-secret-token"}
+	hooks := []Hook{{Command: "sh", Args: []string{"-c", "echo token=$GITFORMER_VAR_API_TOKEN; printf 'stderr hook-secret-token' >&2"}}}
+
+	var out bytes.Buffer
+	err := RunHooks(&out, HookStagePostWrite, hooks, t.TempDir(), input_data, nil)
+	if err != nil {
+		t.Fatalf("RunHooks() error = %v", err)
+	}
+	if strings.Contains(out.String(), "hook-secret-token") {
+		t.Errorf("RunHooks() printed the secret answer: %q", out.String())
+	}
+	if !strings.Contains(out.String(), "token=******\n") || !strings.Contains(out.String(), "stderr ******") {
+		t.Errorf("RunHooks() printed %q, want the hook output redacted", out.String())
+	}
+}
+
+func TestStageOutputs(t *testing.T) {
+	staging_dir, err := StageOutputs(map[string]string{"terraform/www.tf": "name =   \"www\"\n"})
+	if err != nil {
+		t.Fatalf("StageOutputs() error = %v", err)
+	}
+	defer os.RemoveAll(staging_dir)
+
+	hooks := []Hook{{Command: "sh", Args: []string{"-c", "sed -i 's/  */ /g' $GITFORMER_OUTPUT_FILES"}}}
+	if err := RunHooks(io.Discard, HookStagePostRender, hooks, staging_dir, nil, []string{"terraform/www.tf"}); err != nil {
+		t.Fatalf("RunHooks() error = %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join(staging_dir, "terraform/www.tf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "name = \"www\"\n" {
+		t.Errorf("staged output = %q after the hook", got)
+	}
+}
+
+func TestRestoreFileCreatedDirs(t *testing.T) {
+	output_root := t.TempDir()
+	outputFilePath := filepath.Join(output_root, "terraform", "zones", "www.tf")
+	backup, err := BackupFile(outputFilePath)
+	if err != nil {
+		t.Fatalf("BackupFile() error = %v", err)
+	}
+	if backup.CreatedDir != filepath.Join(output_root, "terraform") {
+		t.Errorf("BackupFile() CreatedDir = %v", backup.CreatedDir)
+	}
+	if err := SaveToOutputFile(outputFilePath, "name = \"www\"\n", false, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := RestoreFile(backup); err != nil {
+		t.Fatalf("RestoreFile() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(output_root, "terraform")); !os.IsNotExist(err) {
+		t.Errorf("RestoreFile() left the created directories behind: %v", err)
+	}
+}
@@ -0,0 +1,178 @@
+/*
+Copyright (c) 2023 Peach Pie Labs, LLC.
+*/
+
+package playbook
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// The manifest records every playbook run (a generation) relative to the
+// directory the outputs were rendered into, so that generated files can later
+// be updated, checked for drift, or removed.
+const (
+	ManifestDir      = ".gitformer"
+	ManifestFileName = "manifest.yaml"
+)
+
+const (
+	OutputModeCreate    = "create"
+	OutputModeOverwrite = "overwrite"
+	OutputModeAppend    = "append"
+)
+
+type Manifest struct {
+	Generations []Generation `yaml:"generations"`
+}
+
+type Generation struct {
+	ID        string                 `yaml:"id"`
+	Playbook  string                 `yaml:"playbook"`
+	CreatedAt string                 `yaml:"createdAt"`
+	Answers   map[string]interface{} `yaml:"answers"`
+	// Secrets lists the secret answers left out of Answers
+	Secrets []string        `yaml:"secrets,omitempty"`
+	Files   []GeneratedFile `yaml:"files"`
+}
+
+type GeneratedFile struct {
+	TemplateFile string `yaml:"templateFile"`
+	OutputFile   string `yaml:"outputFile"`
+	// Template holds the template source used for the generation, so the
+	// original output can be rendered again once the template has changed.
+	Template string `yaml:"template"`
+	// Output holds the file as written when hooks changed the rendered
+	// template, as rendering the template again does not run the hooks
+	Output   string   `yaml:"output,omitempty"`
+	Checksum string   `yaml:"checksum"`
+	Mode     string   `yaml:"mode"`
+	Format   []string `yaml:"format,omitempty"`
+	// Item and Index are set for outputs rendered once per item with forEach
+	Item  interface{} `yaml:"item,omitempty"`
+	Index *int        `yaml:"index,omitempty"`
+	// Secret is set when the template uses secret answers, so the file
+	// cannot be rendered again and only hand edits can be checked
+	Secret bool `yaml:"secret,omitempty"`
+}
+
+func ManifestPath(root string) string {
+	return filepath.Join(root, ManifestDir, ManifestFileName)
+}
+
+func LoadManifest(root string) (Manifest, error) {
+	var manifest Manifest
+	byteValue, err := os.ReadFile(ManifestPath(root))
+	if os.IsNotExist(err) {
+		return manifest, nil
+	}
+	if err != nil {
+		return manifest, err
+	}
+	err = yaml.Unmarshal(byteValue, &manifest)
+	if err != nil {
+		return Manifest{}, fmt.Errorf("invalid manifest %s: %w", ManifestPath(root), err)
+	}
+	return manifest, nil
+}
+
+func SaveManifest(root string, manifest Manifest) error {
+	byteValue, err := yaml.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Join(root, ManifestDir), os.ModePerm)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(ManifestPath(root), byteValue, 0644)
+}
+
+func (m *Manifest) FindGeneration(id string) (*Generation, error) {
+	for i := range m.Generations {
+		if m.Generations[i].ID == id {
+			return &m.Generations[i], nil
+		}
+	}
+	return nil, fmt.Errorf("generation %s not found in manifest", id)
+}
+
+func (m *Manifest) DeleteGeneration(id string) {
+	for i := range m.Generations {
+		if m.Generations[i].ID == id {
+			m.Generations = append(m.Generations[:i], m.Generations[i+1:]...)
+			return
+		}
+	}
+}
+
+// NewGeneration records a run of a playbook with its answers, leaving out the
+// secret ones.
+func NewGeneration(playbook_file string, input_data map[string]interface{}, secrets map[string]bool) Generation {
+	suffix := make([]byte, 3)
+	_, _ = rand.Read(suffix)
+	now := time.Now().UTC()
+	generation := Generation{
+		ID:        now.Format("20060102150405") + "-" + hex.EncodeToString(suffix),
+		Playbook:  playbook_file,
+		CreatedAt: now.Format(time.RFC3339),
+		Answers:   OmitSecrets(input_data, secrets),
+	}
+	for name := range secrets {
+		if _, ok := input_data[name]; ok {
+			generation.Secrets = append(generation.Secrets, name)
+		}
+	}
+	sort.Strings(generation.Secrets)
+	return generation
+}
+
+// NewGeneratedFile describes an output written under root. The checksum covers
+// renderedFileContents, which for appended outputs is only the appended block.
+func NewGeneratedFile(root string, output Output, template_source string, outputFilePath string, renderedFileContents string, mode string) (GeneratedFile, error) {
+	relativePath, err := filepath.Rel(root, outputFilePath)
+	if err != nil {
+		return GeneratedFile{}, err
+	}
+	return GeneratedFile{
+		TemplateFile: output.TemplateFile,
+		OutputFile:   filepath.ToSlash(relativePath),
+		Template:     template_source,
+		Checksum:     Checksum(renderedFileContents),
+		Mode:         mode,
+		Format:       output.Format,
+	}, nil
+}
+
+// InputData returns the data the file was rendered with, adding its forEach
+// item to the answers of the generation.
+func (f GeneratedFile) InputData(answers map[string]interface{}) map[string]interface{} {
+	if f.Index == nil {
+		return answers
+	}
+	return ItemInputData(answers, f.Item, *f.Index)
+}
+
+// renderGeneratedFile renders a file of a generation from template_source the
+// same way it was rendered when generated, apart from the hooks.
+func renderGeneratedFile(generation Generation, file GeneratedFile, template_source string) (string, error) {
+	renderedFileContents, err := RenderTemplateString(filepath.Base(file.TemplateFile), template_source, file.InputData(generation.Answers))
+	if err != nil {
+		return "", err
+	}
+	return FormatOutput(renderedFileContents, file.Format)
+}
+
+func Checksum(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
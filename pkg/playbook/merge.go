@@ -0,0 +1,94 @@
+/*
+Copyright (c) 2023 Peach Pie Labs, LLC.
+*/
+
+package playbook
+
+import (
+	"strings"
+)
+
+const (
+	conflictStartMarker  = "<<<<<<< current"
+	conflictMiddleMarker = "======="
+	conflictEndMarker    = ">>>>>>> template"
+)
+
+type MergeConflict struct {
+	// Line is the 1-based line of the conflict start marker in the merged content
+	Line     int
+	Current  []string
+	Template []string
+}
+
+type MergeResult struct {
+	Content   string
+	Conflicts []MergeConflict
+}
+
+// ThreeWayMerge merges the changes made between base and current (usually
+// hand edits) with the changes made between base and template (usually a new
+// template version). Hunks changed on both sides are wrapped in conflict markers.
+func ThreeWayMerge(base, current, template string) MergeResult {
+	baseLines := splitLines(base)
+	currentLines := splitLines(current)
+	templateLines := splitLines(template)
+	currentMatches := matchLines(baseLines, currentLines)
+	templateMatches := matchLines(baseLines, templateLines)
+
+	var merged []string
+	var conflicts []MergeConflict
+	resolve := func(baseHunk, currentHunk, templateHunk []string) {
+		if equalLines(currentHunk, baseHunk) {
+			merged = append(merged, templateHunk...)
+		} else if equalLines(templateHunk, baseHunk) || equalLines(currentHunk, templateHunk) {
+			merged = append(merged, currentHunk...)
+		} else {
+			conflicts = append(conflicts, MergeConflict{Line: len(merged) + 1, Current: currentHunk, Template: templateHunk})
+			merged = append(merged, conflictStartMarker+"\n")
+			merged = append(merged, terminateLines(currentHunk)...)
+			merged = append(merged, conflictMiddleMarker+"\n")
+			merged = append(merged, terminateLines(templateHunk)...)
+			merged = append(merged, conflictEndMarker+"\n")
+		}
+	}
+
+	i, c, t := 0, 0, 0
+	for {
+		// Copy lines that are unchanged on both sides
+		for i < len(baseLines) && currentMatches[i] == c && templateMatches[i] == t {
+			merged = append(merged, baseLines[i])
+			i++
+			c++
+			t++
+		}
+		if i == len(baseLines) && c == len(currentLines) && t == len(templateLines) {
+			break
+		}
+
+		// Find the next base line kept by both sides
+		k := i
+		for k < len(baseLines) && (currentMatches[k] < 0 || templateMatches[k] < 0) {
+			k++
+		}
+		if k == len(baseLines) {
+			resolve(baseLines[i:], currentLines[c:], templateLines[t:])
+			break
+		}
+		resolve(baseLines[i:k], currentLines[c:currentMatches[k]], templateLines[t:templateMatches[k]])
+		i, c, t = k, currentMatches[k], templateMatches[k]
+	}
+
+	return MergeResult{Content: strings.Join(merged, ""), Conflicts: conflicts}
+}
+
+// terminateLines makes sure the last line ends with a newline so that
+// a conflict marker following it starts on its own line.
+func terminateLines(lines []string) []string {
+	if len(lines) == 0 || strings.HasSuffix(lines[len(lines)-1], "\n") {
+		return lines
+	}
+	terminated := append([]string{}, lines...)
+	terminated[len(terminated)-1] += "\n"
+	return terminated
+}
@@ -0,0 +1,76 @@
+/*
+Copyright (c) 2023 Peach Pie Labs, LLC.
+*/
+
+package playbook
+
+import (
+	"testing"
+)
+
+func TestThreeWayMerge(t *testing.T) {
+	tests := []struct {
+		name          string
+		base          string
+		current       string
+		template      string
+		want          string
+		wantConflicts int
+	}{
+		{
+			name:     "template_change_only",
+			base:     "a\nb\nc\n",
+			current:  "a\nb\nc\n",
+			template: "a\nB\nc\n",
+			want:     "a\nB\nc\n",
+		},
+		{
+			name:     "hand_edit_only",
+			base:     "a\nb\nc\n",
+			current:  "a\nb\nc\nd\n",
+			template: "a\nb\nc\n",
+			want:     "a\nb\nc\nd\n",
+		},
+		{
+			name:     "separate_hunks",
+			base:     "a\nb\nc\nd\ne\n",
+			current:  "a\nb2\nc\nd\ne\n",
+			template: "a\nb\nc\nd\ne2\n",
+			want:     "a\nb2\nc\nd\ne2\n",
+		},
+		{
+			name:     "same_change_on_both_sides",
+			base:     "a\nb\nc\n",
+			current:  "a\nx\nc\n",
+			template: "a\nx\nc\n",
+			want:     "a\nx\nc\n",
+		},
+		{
+			name:          "clashing_hunks",
+			base:          "a\nb\nc\n",
+			current:       "a\nmine\nc\n",
+			template:      "a\ntheirs\nc\n",
+			want:          "a\n<<<<<<< current\nmine\n=======\ntheirs\n>>>>>>> template\nc\n",
+			wantConflicts: 1,
+		},
+		{
+			name:          "clash_without_final_newline",
+			base:          "a\nb",
+			current:       "a\nmine",
+			template:      "a\ntheirs",
+			want:          "a\n<<<<<<< current\nmine\n=======\ntheirs\n>>>>>>> template\n",
+			wantConflicts: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ThreeWayMerge(tt.base, tt.current, tt.template)
+			if got.Content != tt.want {
+				t.Errorf("ThreeWayMerge() content = %q, want %q", got.Content, tt.want)
+			}
+			if len(got.Conflicts) != tt.wantConflicts {
+				t.Errorf("ThreeWayMerge() conflicts = %v, want %v", len(got.Conflicts), tt.wantConflicts)
+			}
+		})
+	}
+}
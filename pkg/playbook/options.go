@@ -0,0 +1,274 @@
+/*
+Copyright (c) 2023 Peach Pie Labs, LLC.
+*/
+
+package playbook
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	OptionsKindDir  = "dir"
+	OptionsKindFile = "file"
+)
+
+// OptionsFrom reads the options of a select question from the output root
+// when the question is asked, so that they follow the repository:
+//
+//   - glob lists the base names of the matching files and directories,
+//     optionally only of one kind
+//   - file lists the lines of a file, ignoring blank lines and # comments
+//   - file with path lists the keys or the items found at a dotted path in a
+//     YAML or JSON file
+//   - glob or file with regex lists the first capture group of every match of
+//     the expression in the matching files
+type OptionsFrom struct {
+	Glob  string `yaml:"glob,omitempty"`
+	Kind  string `yaml:"kind,omitempty"`
+	File  string `yaml:"file,omitempty"`
+	Path  string `yaml:"path,omitempty"`
+	Regex string `yaml:"regex,omitempty"`
+}
+
+func validateOptionsFrom(question Question) error {
+	options := question.OptionsFrom
+	if options == nil {
+		return nil
+	}
+	if question.InputType != "select" {
+		return fmt.Errorf("optionsFrom of question %s is only allowed with inputType select", question.VariableName)
+	}
+	if (options.Glob == "") == (options.File == "") {
+		return fmt.Errorf("optionsFrom of question %s must have either a glob or a file", question.VariableName)
+	}
+	if options.Kind != "" {
+		if options.Glob == "" || options.Regex != "" {
+			return fmt.Errorf("optionsFrom kind of question %s is only allowed with a glob and without a regex", question.VariableName)
+		}
+		if options.Kind != OptionsKindDir && options.Kind != OptionsKindFile {
+			return fmt.Errorf("invalid optionsFrom kind %q for question %s. valid values are %s and %s", options.Kind, question.VariableName, OptionsKindDir, OptionsKindFile)
+		}
+	}
+	if options.Path != "" && (options.File == "" || options.Regex != "") {
+		return fmt.Errorf("optionsFrom path of question %s is only allowed with a file and without a regex", question.VariableName)
+	}
+	if options.Regex != "" {
+		if _, err := regexp.Compile(options.Regex); err != nil {
+			return fmt.Errorf("invalid optionsFrom regex for question %s. %w", question.VariableName, err)
+		}
+	}
+	return nil
+}
+
+// ResolveOptions returns the question with the options found from
+// OptionsFrom under output_root, and listed by OptionsCommand, added to its
+// valid values. When the command fails, a warning is printed to out and the
+// question offers its other options, or is asked as free text when it has
+// none.
+func ResolveOptions(out io.Writer, question Question, output_root string, input_data map[string]interface{}) (Question, error) {
+	if question.OptionsFrom == nil && question.OptionsCommand == nil {
+		return question, nil
+	}
+	validValues := append([]string{}, question.ValidValues...)
+	var selectOptions []SelectOption
+	if question.hasOptions() {
+		selectOptions = append([]SelectOption{}, question.Options...)
+	}
+	seen := make(map[string]bool)
+	for _, value := range validValues {
+		seen[value] = true
+	}
+	add := func(options []string) {
+		for _, option := range options {
+			if !seen[option] {
+				seen[option] = true
+				validValues = append(validValues, option)
+				if selectOptions != nil {
+					selectOptions = append(selectOptions, SelectOption{Value: option})
+				}
+			}
+		}
+	}
+	if question.OptionsFrom != nil {
+		options, err := readOptions(*question.OptionsFrom, output_root)
+		if err != nil {
+			return question, fmt.Errorf("options of question %s: %w", question.VariableName, err)
+		}
+		add(options)
+	}
+	if question.OptionsCommand != nil {
+		options, err := runOptionsCommand(*question.OptionsCommand, output_root, input_data)
+		if err != nil && len(validValues) > 0 {
+			fmt.Fprintf(out, "warning: could not list the options of question %s, only its other options are offered: %v\n", question.VariableName, err)
+		} else if err != nil {
+			fmt.Fprintf(out, "warning: could not list the options of question %s, answer it as free text: %v\n", question.VariableName, err)
+			question.InputType = "textfield"
+			question.ValidValues = nil
+			question.Options = nil
+			return question, nil
+		}
+		add(options)
+	}
+	if len(validValues) == 0 {
+		return question, fmt.Errorf("no options found for question %s", question.VariableName)
+	}
+	question.ValidValues = validValues
+	question.Options = selectOptions
+	return question, nil
+}
+
+func readOptions(options OptionsFrom, output_root string) ([]string, error) {
+	var files []string
+	if options.Glob != "" {
+		matches, err := filepath.Glob(filepath.Join(output_root, filepath.FromSlash(options.Glob)))
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(matches)
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil {
+				return nil, err
+			}
+			if (options.Kind == OptionsKindDir && !info.IsDir()) || (options.Kind == OptionsKindFile && info.IsDir()) {
+				continue
+			}
+			if options.Regex != "" && info.IsDir() {
+				continue
+			}
+			files = append(files, match)
+		}
+		if options.Regex == "" {
+			names := make([]string, 0, len(files))
+			for _, file := range files {
+				names = append(names, filepath.Base(file))
+			}
+			return names, nil
+		}
+	} else {
+		files = []string{filepath.Join(output_root, filepath.FromSlash(options.File))}
+	}
+
+	if options.Regex != "" {
+		pattern, err := regexp.Compile(options.Regex)
+		if err != nil {
+			return nil, err
+		}
+		var values []string
+		for _, file := range files {
+			contents, err := os.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			for _, match := range pattern.FindAllStringSubmatch(string(contents), -1) {
+				if len(match) > 1 {
+					values = append(values, match[1])
+				} else {
+					values = append(values, match[0])
+				}
+			}
+		}
+		return values, nil
+	}
+	if options.Path != "" {
+		return readOptionsAtPath(files[0], options.Path)
+	}
+	return readOptionLines(files[0])
+}
+
+func readOptionLines(file string) ([]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// readOptionsAtPath lists the keys of the mapping or the items of the list
+// found at a dotted path such as environments.prod.regions in a YAML or JSON file.
+func readOptionsAtPath(file string, path string) ([]string, error) {
+	contents, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var document yamlDocument
+	err = yaml.Unmarshal(contents, &document)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", file, err)
+	}
+	node := document.value
+	for _, segment := range strings.Split(path, ".") {
+		switch current := node.(type) {
+		case map[interface{}]interface{}:
+			value, ok := current[segment]
+			if !ok {
+				return nil, fmt.Errorf("%s not found in %s", path, file)
+			}
+			node = value
+		case yaml.MapSlice:
+			found := false
+			for _, item := range current {
+				if fmt.Sprint(item.Key) == segment {
+					node, found = item.Value, true
+					break
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("%s not found in %s", path, file)
+			}
+		case []interface{}:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(current) {
+				return nil, fmt.Errorf("%s not found in %s", path, file)
+			}
+			node = current[index]
+		default:
+			return nil, fmt.Errorf("%s not found in %s", path, file)
+		}
+	}
+
+	var values []string
+	switch node := node.(type) {
+	case yaml.MapSlice:
+		for _, item := range node {
+			values = append(values, fmt.Sprint(item.Key))
+		}
+	case map[interface{}]interface{}:
+		for key := range node {
+			values = append(values, fmt.Sprint(key))
+		}
+		sort.Strings(values)
+	case []interface{}:
+		for _, item := range node {
+			switch item.(type) {
+			case yaml.MapSlice, map[interface{}]interface{}, []interface{}:
+				return nil, fmt.Errorf("%s in %s must hold a mapping or a list of values", path, file)
+			}
+			values = append(values, fmt.Sprint(item))
+		}
+	default:
+		return nil, fmt.Errorf("%s in %s is not a mapping or a list", path, file)
+	}
+	return values, nil
+}
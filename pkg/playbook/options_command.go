@@ -0,0 +1,160 @@
+/*
+Copyright (c) 2023 Peach Pie Labs, LLC.
+*/
+
+package playbook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	OptionsFormatLines = "lines"
+	OptionsFormatJSON  = "json"
+)
+
+const defaultOptionsCommandTimeout = 10 * time.Second
+
+// OptionsCommand runs a command, such as kubectl config get-contexts -o name,
+// to list the options of a select question. The output is read one option
+// per line, or as a JSON list of values or object whose keys are the options.
+type OptionsCommand struct {
+	// Command, Args and Dir are templates rendered with the answers
+	// collected so far
+	Command string   `yaml:"command"`
+	Args    []string `yaml:"args,omitempty"`
+	// Dir is the working directory relative to the output root
+	Dir     string `yaml:"dir,omitempty"`
+	Timeout string `yaml:"timeout,omitempty"`
+	Format  string `yaml:"format,omitempty"`
+}
+
+// optionsCommandCache keeps the options listed by each command for the rest
+// of the run, so that a command runs once even when a question is asked again
+// or answered for many targets with the same answers.
+var optionsCommandCache = struct {
+	sync.Mutex
+	options map[string][]string
+}{options: map[string][]string{}}
+
+func validateOptionsCommand(question Question) error {
+	command := question.OptionsCommand
+	if command == nil {
+		return nil
+	}
+	if question.InputType != "select" {
+		return fmt.Errorf("optionsCommand of question %s is only allowed with inputType select", question.VariableName)
+	}
+	if command.Command == "" {
+		return fmt.Errorf("no command given in the optionsCommand of question %s", question.VariableName)
+	}
+	if command.Timeout != "" {
+		if _, err := time.ParseDuration(command.Timeout); err != nil {
+			return fmt.Errorf("invalid timeout %q in the optionsCommand of question %s", command.Timeout, question.VariableName)
+		}
+	}
+	if command.Format != "" && command.Format != OptionsFormatLines && command.Format != OptionsFormatJSON {
+		return fmt.Errorf("invalid optionsCommand format %q for question %s. valid values are %s and %s", command.Format, question.VariableName, OptionsFormatLines, OptionsFormatJSON)
+	}
+	return nil
+}
+
+// runOptionsCommand returns the options listed by a command run in output_root.
+func runOptionsCommand(command OptionsCommand, output_root string, input_data map[string]interface{}) ([]string, error) {
+	dir := filepath.Join(output_root, command.Dir)
+	// The command may depend on the answers passed in its environment
+	env := answerEnv(input_data)
+	sort.Strings(env)
+	key := strings.Join(append(append([]string{dir, command.Format, command.Command}, command.Args...), env...), "\x00")
+	optionsCommandCache.Lock()
+	options, ok := optionsCommandCache.options[key]
+	optionsCommandCache.Unlock()
+	if ok {
+		return options, nil
+	}
+
+	timeout := defaultOptionsCommandTimeout
+	if command.Timeout != "" {
+		var err error
+		timeout, err = time.ParseDuration(command.Timeout)
+		if err != nil {
+			return nil, err
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, command.Command, command.Args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("%s timed out after %v", command.Command, timeout)
+	}
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, fmt.Errorf("%s: %w: %s", command.Command, err, message)
+		}
+		return nil, fmt.Errorf("%s: %w", command.Command, err)
+	}
+
+	if command.Format == OptionsFormatJSON {
+		options, err = parseJSONOptions(stdout.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", command.Command, err)
+		}
+	} else {
+		for _, line := range strings.Split(stdout.String(), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				options = append(options, line)
+			}
+		}
+	}
+	if len(options) == 0 {
+		return nil, fmt.Errorf("%s listed no options", command.Command)
+	}
+
+	optionsCommandCache.Lock()
+	optionsCommandCache.options[key] = options
+	optionsCommandCache.Unlock()
+	return options, nil
+}
+
+func parseJSONOptions(output []byte) ([]string, error) {
+	var value interface{}
+	if err := json.Unmarshal(output, &value); err != nil {
+		return nil, fmt.Errorf("invalid JSON output: %w", err)
+	}
+	var options []string
+	switch value := value.(type) {
+	case []interface{}:
+		for _, item := range value {
+			switch item.(type) {
+			case map[string]interface{}, []interface{}:
+				return nil, errors.New("JSON output must be a list of values or an object")
+			}
+			options = append(options, fmt.Sprint(item))
+		}
+	case map[string]interface{}:
+		for key := range value {
+			options = append(options, key)
+		}
+		sort.Strings(options)
+	default:
+		return nil, errors.New("JSON output must be a list of values or an object")
+	}
+	return options, nil
+}
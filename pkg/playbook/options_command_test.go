@@ -0,0 +1,116 @@
+/*
+Copyright (c) 2023 Peach Pie Labs, LLC.
+*/
+
+package playbook
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResolveOptionsCommand(t *testing.T) {
+	output_root := t.TempDir()
+	tests := []struct {
+		name          string
+		command       OptionsCommand
+		validValues   []string
+		wantInputType string
+		want          []string
+	}{
+		{
+			name:          "lines",
+			command:       OptionsCommand{Command: "sh", Args: []string{"-c", "printf 'dev\\n\\nprod\\n'"}},
+			wantInputType: "select",
+			want:          []string{"dev", "prod"},
+		},
+		{
+			name:          "json_list",
+			command:       OptionsCommand{Command: "echo", Args: []string{`["us-east1", "europe-west1", 3]`}, Format: OptionsFormatJSON},
+			wantInputType: "select",
+			want:          []string{"us-east1", "europe-west1", "3"},
+		},
+		{
+			name:          "json_object",
+			command:       OptionsCommand{Command: "echo", Args: []string{`{"staging": {}, "default": {}}`}, Format: OptionsFormatJSON},
+			wantInputType: "select",
+			want:          []string{"default", "staging"},
+		},
+		{
+			name:          "answers",
+			command:       OptionsCommand{Command: "sh", Args: []string{"-c", "echo $GITFORMER_VAR_CLUSTER_NAME-a"}},
+			wantInputType: "select",
+			want:          []string{"web-a"},
+		},
+		{
+			name:          "failure",
+			command:       OptionsCommand{Command: "sh", Args: []string{"-c", "echo no context >&2; exit 1"}},
+			wantInputType: "textfield",
+		},
+		{
+			name:          "timeout",
+			command:       OptionsCommand{Command: "sleep", Args: []string{"5"}, Timeout: "100ms"},
+			wantInputType: "textfield",
+		},
+		{
+			name:          "invalid_json",
+			command:       OptionsCommand{Command: "echo", Args: []string{"dev"}, Format: OptionsFormatJSON},
+			wantInputType: "textfield",
+		},
+		{
+			name:          "no_options",
+			command:       OptionsCommand{Command: "true"},
+			wantInputType: "textfield",
+		},
+		{
+			name:          "failure_with_valid_values",
+			command:       OptionsCommand{Command: "false"},
+			validValues:   []string{"default"},
+			want:          []string{"default"},
+			wantInputType: "select",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			command := tt.command
+			question := Question{VariableName: "choice", InputType: "select", ValidValues: tt.validValues, OptionsCommand: &command}
+			got, err := ResolveOptions(io.Discard, question, output_root, map[string]interface{}{"cluster_name": "web"})
+			if err != nil {
+				t.Fatalf("ResolveOptions() error = %v", err)
+			}
+			if got.InputType != tt.wantInputType {
+				t.Errorf("ResolveOptions() inputType = %v, want %v", got.InputType, tt.wantInputType)
+			}
+			if !reflect.DeepEqual(got.ValidValues, tt.want) {
+				t.Errorf("ResolveOptions() = %v, want %v", got.ValidValues, tt.want)
+			}
+		})
+	}
+}
+
+func TestOptionsCommandCache(t *testing.T) {
+	output_root := t.TempDir()
+	command := OptionsCommand{Command: "sh", Args: []string{"-c", "echo run >> runs.txt; echo $GITFORMER_VAR_CLUSTER_NAME-dev"}}
+	question := Question{VariableName: "environment", InputType: "select", OptionsCommand: &command}
+	// Answers other than the first ones run the command again
+	for _, cluster_name := range []string{"web", "web", "api"} {
+		got, err := ResolveOptions(io.Discard, question, output_root, map[string]interface{}{"cluster_name": cluster_name})
+		if err != nil {
+			t.Fatalf("ResolveOptions() error = %v", err)
+		}
+		if want := []string{cluster_name + "-dev"}; !reflect.DeepEqual(got.ValidValues, want) {
+			t.Errorf("ResolveOptions() = %v, want %v", got.ValidValues, want)
+		}
+	}
+	runs, err := os.ReadFile(filepath.Join(output_root, "runs.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count := strings.Count(string(runs), "run"); count != 2 {
+		t.Errorf("options command ran %d times, want 2", count)
+	}
+}
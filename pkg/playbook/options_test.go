@@ -0,0 +1,97 @@
+/*
+Copyright (c) 2023 Peach Pie Labs, LLC.
+*/
+
+package playbook
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestResolveOptions(t *testing.T) {
+	output_root := t.TempDir()
+	for _, dir := range []string{"environments/dev", "environments/prod", "modules/network", "modules/dns"} {
+		if err := os.MkdirAll(filepath.Join(output_root, dir), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files := map[string]string{
+		"environments/README.md":    "# Environments\n",
+		"regions.txt":               "# supported regions\nus-east1\n\n  europe-west1  \n",
+		"config.yaml":               "clusters:\n  web:\n    zones: [a, b]\n  api:\n    zones: [c]\n",
+		"config.json":               `{"teams": ["platform", "data"]}`,
+		"modules/network/main.tf":   "module \"vpc\" {\n}\nmodule \"subnets\" {\n}\n",
+		"modules/dns/main.tf":       "module \"zone\" {\n}\n",
+		"modules/dns/variables.txt": "module \"ignored\" {\n}\n",
+	}
+	for name, contents := range files {
+		if err := os.WriteFile(filepath.Join(output_root, name), []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		validValues []string
+		options     OptionsFrom
+		want        []string
+		wantErr     bool
+	}{
+		{name: "glob", options: OptionsFrom{Glob: "environments/*"}, want: []string{"README.md", "dev", "prod"}},
+		{name: "glob_directories", options: OptionsFrom{Glob: "environments/*", Kind: OptionsKindDir}, want: []string{"dev", "prod"}},
+		{name: "glob_files", options: OptionsFrom{Glob: "environments/*", Kind: OptionsKindFile}, want: []string{"README.md"}},
+		{name: "lines", options: OptionsFrom{File: "regions.txt"}, want: []string{"us-east1", "europe-west1"}},
+		{name: "yaml_keys", options: OptionsFrom{File: "config.yaml", Path: "clusters"}, want: []string{"web", "api"}},
+		{name: "yaml_items", options: OptionsFrom{File: "config.yaml", Path: "clusters.web.zones"}, want: []string{"a", "b"}},
+		{name: "json_items", options: OptionsFrom{File: "config.json", Path: "teams"}, want: []string{"platform", "data"}},
+		{name: "regex", options: OptionsFrom{Glob: "modules/*/*.tf", Regex: `module "([^"]+)"`}, want: []string{"zone", "vpc", "subnets"}},
+		{name: "with_valid_values", validValues: []string{"none", "vpc"}, options: OptionsFrom{Glob: "modules/*/*.tf", Regex: `module "([^"]+)"`}, want: []string{"none", "vpc", "zone", "subnets"}},
+		{name: "missing_path", options: OptionsFrom{File: "config.yaml", Path: "clusters.db"}, wantErr: true},
+		{name: "missing_file", options: OptionsFrom{File: "teams.txt"}, wantErr: true},
+		{name: "no_options", options: OptionsFrom{Glob: "charts/*"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := tt.options
+			question := Question{VariableName: "choice", InputType: "select", ValidValues: tt.validValues, OptionsFrom: &options}
+			got, err := ResolveOptions(io.Discard, question, output_root, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResolveOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got.ValidValues, tt.want) {
+				t.Errorf("ResolveOptions() = %v, want %v", got.ValidValues, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateOptionsFrom(t *testing.T) {
+	tests := []struct {
+		name      string
+		inputType string
+		options   OptionsFrom
+		wantErr   bool
+	}{
+		{name: "glob", inputType: "select", options: OptionsFrom{Glob: "environments/*", Kind: OptionsKindDir}},
+		{name: "file_path", inputType: "select", options: OptionsFrom{File: "config.yaml", Path: "clusters"}},
+		{name: "textfield", inputType: "textfield", options: OptionsFrom{Glob: "environments/*"}, wantErr: true},
+		{name: "glob_and_file", inputType: "select", options: OptionsFrom{Glob: "*", File: "a.txt"}, wantErr: true},
+		{name: "neither", inputType: "select", options: OptionsFrom{Regex: "a"}, wantErr: true},
+		{name: "invalid_kind", inputType: "select", options: OptionsFrom{Glob: "*", Kind: "link"}, wantErr: true},
+		{name: "path_with_glob", inputType: "select", options: OptionsFrom{Glob: "*", Path: "a"}, wantErr: true},
+		{name: "invalid_regex", inputType: "select", options: OptionsFrom{File: "a.txt", Regex: "("}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := tt.options
+			err := validateOptionsFrom(Question{VariableName: "choice", InputType: tt.inputType, OptionsFrom: &options})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateOptionsFrom() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
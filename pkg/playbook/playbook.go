@@ -15,7 +15,9 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"reflect"
 	"strings"
+	texttemplate "text/template"
 	"time"
 
 	"github.com/Masterminds/sprig/v3"
@@ -26,25 +28,45 @@ import (
 )
 
 type Playbook struct {
-	Name        string     `yaml:"name,omitempty"`
-	Description string     `yaml:"description,omitempty"`
-	Questions   []Question `yaml:"questions,omitempty"`
-	Outputs     []Output   `yaml:"outputs"`
+	Name        string      `yaml:"name,omitempty"`
+	Description string      `yaml:"description,omitempty"`
+	Questions   []Question  `yaml:"questions,omitempty"`
+	Variables   Variables   `yaml:"variables,omitempty"`
+	Outputs     []Output    `yaml:"outputs"`
+	Git         *GitOptions `yaml:"git,omitempty"`
+	Hooks       *Hooks      `yaml:"hooks,omitempty"`
 }
 
 type Question struct {
-	Prompt                string        `yaml:"prompt,omitempty"`
-	Placeholder           string        `yaml:"placeholder,omitempty"`
-	Required              bool          `yaml:"required,omitempty"`
-	VariableName          string        `yaml:"variableName"`
-	InputType             string        `yaml:"inputType"`
-	VariableType          string        `yaml:"variableType"`
-	Default               string        `yaml:"default,omitempty"`
-	ValidValues           []string      `yaml:"validValues,omitempty"`
-	Validation            string        `yaml:"validation,omitempty"`
-	CustomRegexValidation string        `yaml:"customRegexValidation,omitempty"`
-	Range                 *IntegerRange `yaml:"range,omitempty"`
-	ValidPatterns         []string      `yaml:"validPatterns,omitempty"`
+	Prompt       string `yaml:"prompt,omitempty"`
+	Placeholder  string `yaml:"placeholder,omitempty"`
+	Required     bool   `yaml:"required,omitempty"`
+	VariableName string `yaml:"variableName"`
+	InputType    string `yaml:"inputType"`
+	VariableType string `yaml:"variableType"`
+	Default      string `yaml:"default,omitempty"`
+	// ValidValues holds the values of the validValues entries as strings,
+	// and Options the entries themselves when some are given as objects
+	ValidValues           []string         `yaml:"-"`
+	Options               []SelectOption   `yaml:"validValues,omitempty"`
+	Validation            string           `yaml:"validation,omitempty"`
+	CustomRegexValidation string           `yaml:"customRegexValidation,omitempty"`
+	Range                 *IntegerRange    `yaml:"range,omitempty"`
+	FloatRange            *FloatRange      `yaml:"floatRange,omitempty"`
+	ValidPatterns         []string         `yaml:"validPatterns,omitempty"`
+	Validations           []ValidationRule `yaml:"validations,omitempty"`
+	OptionsFrom           *OptionsFrom     `yaml:"optionsFrom,omitempty"`
+	OptionsCommand        *OptionsCommand  `yaml:"optionsCommand,omitempty"`
+	// PageSize is the number of options a select question lists at a time
+	PageSize int `yaml:"pageSize,omitempty"`
+	// Secret answers are left out of the manifest, logs and telemetry
+	Secret bool `yaml:"secret,omitempty"`
+	// Confirm asks for a password twice
+	Confirm bool `yaml:"confirm,omitempty"`
+	// Env names an environment variable the answer is read from when set
+	Env string `yaml:"env,omitempty"`
+	// Transform changes the answer before it is validated
+	Transform []Transform `yaml:"transform,omitempty"`
 }
 
 type IntegerRange struct {
@@ -52,13 +74,27 @@ type IntegerRange struct {
 	Max *int `yaml:"max"`
 }
 
+type FloatRange struct {
+	Min *float64 `yaml:"min"`
+	Max *float64 `yaml:"max"`
+}
+
 type Output struct {
-	TemplateFile string `yaml:"templateFile,omitempty"`
-	OutputFile   string `yaml:"outputFile,omitempty"`
+	TemplateFile string   `yaml:"templateFile,omitempty"`
+	OutputFile   string   `yaml:"outputFile,omitempty"`
+	Format       []string `yaml:"format,omitempty"`
+	ValidateAs   string   `yaml:"validateAs,omitempty"`
+	Schema       string   `yaml:"schema,omitempty"`
+	When         string   `yaml:"when,omitempty"`
+	ForEach      string   `yaml:"forEach,omitempty"`
 }
 
+// CaptureError sends an error to telemetry, with the secret answers redacted.
 func CaptureError(err error) {
 	if os.Getenv("GITFORMER_TELEMETRY_DISABLED") != "true" {
+		if message := Redact(err.Error()); message != err.Error() {
+			err = errors.New(message)
+		}
 		sentry.CaptureException(err)
 		sentry.Flush(2 * time.Second)
 	}
@@ -105,8 +141,8 @@ func ValidatePlaybook(playbook Playbook, playbook_base_dir string) error {
 				return errors.New("validPatterns is not allowed in customRegexValidation")
 			}
 		} else if question.Validation != "" {
-			if question.ValidPatterns != nil && question.Validation != "url" {
-				return errors.New("validPatterns field comes only with validation=url")
+			if err := validateValidationConfig(question, "question "+question.VariableName); err != nil {
+				return err
 			}
 		}
 
@@ -122,8 +158,29 @@ func ValidatePlaybook(playbook Playbook, playbook_base_dir string) error {
 		if question.VariableType == "" {
 			return errors.New("no variableType provided. every question must have a variable type")
 		}
-		if question.InputType == "select" && (question.ValidValues == nil || len(question.ValidValues) == 0) {
-			return errors.New("select statement does not have a valid value. every select question must have at least one valid value")
+		if question.InputType == "select" && question.OptionsFrom == nil && question.OptionsCommand == nil && (question.ValidValues == nil || len(question.ValidValues) == 0) {
+			return errors.New("select statement does not have a valid value. every select question must have at least one valid value, optionsFrom or optionsCommand")
+		}
+		if question.PageSize < 0 || (question.PageSize > 0 && question.InputType != "select") {
+			return fmt.Errorf("invalid pageSize %d for question %s. pageSize must be positive and is only allowed with inputType select", question.PageSize, question.VariableName)
+		}
+		if question.Confirm && question.InputType != "password" {
+			return fmt.Errorf("confirm of question %s is only allowed with inputType password", question.VariableName)
+		}
+		if err := validateEnv(question); err != nil {
+			return err
+		}
+		if err := validateTransforms(question); err != nil {
+			return err
+		}
+		if err := validateValidationRules(question); err != nil {
+			return err
+		}
+		if err := validateOptionsFrom(question); err != nil {
+			return err
+		}
+		if err := validateOptionsCommand(question); err != nil {
+			return err
 		}
 	}
 
@@ -140,6 +197,40 @@ func ValidatePlaybook(playbook Playbook, playbook_base_dir string) error {
 		if output.OutputFile == "" {
 			return errors.New("no outputFile given in the output. every output must have a template file")
 		}
+		if err := validateFormats(output.Format); err != nil {
+			return err
+		}
+		if err := validateSyntaxName(output.ValidateAs); err != nil {
+			return err
+		}
+		if err := parseText(conditionTemplate(output.When)); err != nil {
+			return fmt.Errorf("invalid when condition %q. %w", output.When, err)
+		}
+		if output.Schema != "" {
+			if _, err := LoadSchema(filepath.Join(playbook_base_dir, output.Schema)); err != nil {
+				return err
+			}
+		}
+	}
+
+	err := validateForEach(playbook)
+	if err != nil {
+		return err
+	}
+
+	err = validateHooks(playbook.Hooks)
+	if err != nil {
+		return err
+	}
+
+	err = validateVariables(playbook)
+	if err != nil {
+		return err
+	}
+
+	err = validateQuestionTemplates(playbook)
+	if err != nil {
+		return err
 	}
 
 	// Load the template files and check that they are valid
@@ -155,6 +246,14 @@ func ValidatePlaybook(playbook Playbook, playbook_base_dir string) error {
 }
 
 func RenderTemplate(playbook_base_dir string, input_data map[string]interface{}, template_filepath string, output_filepath string) (string, string, error) {
+	return RenderTemplateTo(os.Stdout, playbook_base_dir, playbook_base_dir, input_data, template_filepath, output_filepath)
+}
+
+// RenderTemplateTo renders a template from playbook_base_dir for an output file
+// relative to output_root, which is the root of the target repository when the
+// outputs do not belong next to the playbook. The file rendered is printed to
+// out.
+func RenderTemplateTo(out io.Writer, playbook_base_dir string, output_root string, input_data map[string]interface{}, template_filepath string, output_filepath string) (string, string, error) {
 	template_filepath = playbook_base_dir + "/" + template_filepath
 	filenameTemplate := template.Must(template.New("filename").Funcs(sprig.FuncMap()).Parse(output_filepath))
 	var fileTpl bytes.Buffer
@@ -162,8 +261,8 @@ func RenderTemplate(playbook_base_dir string, input_data map[string]interface{},
 	if err != nil {
 		return "", "", err
 	}
-	outputFilePath := playbook_base_dir + "/" + fileTpl.String()
-	fmt.Printf("rendering template %v to %v\n", template_filepath, outputFilePath)
+	outputFilePath := output_root + "/" + fileTpl.String()
+	fmt.Fprintf(out, "rendering template %v to %v\n", template_filepath, outputFilePath)
 
 	tmpl, err := template.New(filepath.Base(template_filepath)).Funcs(sprig.FuncMap()).ParseFiles(template_filepath)
 	if err != nil {
@@ -179,6 +278,153 @@ func RenderTemplate(playbook_base_dir string, input_data map[string]interface{},
 	return renderedFileContents, outputFilePath, nil
 }
 
+func RenderTemplateString(template_name string, template_source string, input_data map[string]interface{}) (string, error) {
+	tmpl, err := template.New(template_name).Funcs(sprig.FuncMap()).Parse(template_source)
+	if err != nil {
+		return "", err
+	}
+	var tpl bytes.Buffer
+	err = tmpl.Execute(&tpl, input_data)
+	if err != nil {
+		return "", err
+	}
+	return tpl.String(), nil
+}
+
+// RenderText renders a template that is not written to a file, such as a
+// branch name or commit message, so its output is not HTML escaped.
+func RenderText(text string, input_data map[string]interface{}) (string, error) {
+	tmpl, err := texttemplate.New("text").Funcs(sprig.TxtFuncMap()).Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var tpl bytes.Buffer
+	err = tmpl.Execute(&tpl, input_data)
+	if err != nil {
+		return "", err
+	}
+	return tpl.String(), nil
+}
+
+// EvaluateCondition reports whether the when condition of an output holds for
+// input_data. The condition is a template, or a template action without the
+// braces such as `eq .cloud "gcp"`. It is false when it renders to an empty
+// string, "false", "no", "off", "0" or "<no value>", and true otherwise. An
+// empty condition is always true.
+func EvaluateCondition(when string, input_data map[string]interface{}) (bool, error) {
+	if strings.TrimSpace(when) == "" {
+		return true, nil
+	}
+	result, err := RenderText(conditionTemplate(when), input_data)
+	if err != nil {
+		return false, err
+	}
+	switch strings.ToLower(strings.TrimSpace(result)) {
+	case "", "false", "no", "off", "0", "<no value>":
+		return false, nil
+	}
+	return true, nil
+}
+
+// ForEachItems returns the items of the variable an output is rendered for
+// with forEach. The value may be a list, or a string of items separated by
+// commas or new lines.
+func ForEachItems(input_data map[string]interface{}, variable string) ([]interface{}, error) {
+	value, ok := input_data[variable]
+	if !ok {
+		return nil, fmt.Errorf("forEach variable %s has no value", variable)
+	}
+	if text, ok := value.(string); ok {
+		var items []interface{}
+		for _, item := range strings.FieldsFunc(text, func(r rune) bool { return r == ',' || r == '\n' }) {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return items, nil
+	}
+	list := reflect.ValueOf(value)
+	if list.Kind() != reflect.Slice && list.Kind() != reflect.Array {
+		return nil, fmt.Errorf("forEach variable %s is not a list", variable)
+	}
+	items := make([]interface{}, list.Len())
+	for i := range items {
+		items[i] = list.Index(i).Interface()
+	}
+	return items, nil
+}
+
+// validateForEach checks that every forEach names a question or a variable,
+// and that no question or variable is hidden by the item and index added to
+// the answers of forEach outputs.
+func validateForEach(playbook Playbook) error {
+	names := make(map[string]bool)
+	for _, question := range playbook.Questions {
+		names[question.VariableName] = true
+	}
+	for name := range playbook.Variables {
+		names[name] = true
+	}
+	for _, output := range playbook.Outputs {
+		if output.ForEach == "" {
+			continue
+		}
+		if !names[output.ForEach] {
+			return fmt.Errorf("forEach %s of output %s is neither a question nor a variable", output.ForEach, output.OutputFile)
+		}
+		for _, name := range []string{"item", "index"} {
+			if names[name] {
+				return fmt.Errorf("%s is set by forEach for output %s. rename the question or variable %s", name, output.OutputFile, name)
+			}
+		}
+	}
+	return nil
+}
+
+// ItemInputData returns a copy of input_data with the current forEach item
+// and its index added as .item and .index.
+func ItemInputData(input_data map[string]interface{}, item interface{}, index int) map[string]interface{} {
+	item_data := make(map[string]interface{}, len(input_data)+2)
+	for key, value := range input_data {
+		item_data[key] = value
+	}
+	item_data["item"] = item
+	item_data["index"] = index
+	return item_data
+}
+
+func conditionTemplate(when string) string {
+	if when == "" || strings.Contains(when, "{{") {
+		return when
+	}
+	return "{{ " + when + " }}"
+}
+
+func parseText(text string) error {
+	_, err := texttemplate.New("text").Funcs(sprig.TxtFuncMap()).Parse(text)
+	return err
+}
+
+func ReadTemplateSource(playbook_base_dir string, template_filepath string) (string, error) {
+	byteValue, err := os.ReadFile(playbook_base_dir + "/" + template_filepath)
+	if err != nil {
+		return "", err
+	}
+	return string(byteValue), nil
+}
+
+// OutputMode reports how SaveToOutputFile will treat the output file given the
+// overwrite and append flags.
+func OutputMode(outputFilePath string, overwriteFlag, appendFlag bool) string {
+	if _, err := os.Stat(outputFilePath); err != nil {
+		return OutputModeCreate
+	}
+	if appendFlag && !overwriteFlag {
+		return OutputModeAppend
+	}
+	return OutputModeOverwrite
+}
+
 func SaveToOutputFile(outputFilePath, renderedFileContents string, overwriteFlag, appendFlag bool) error {
 	_, err := os.Stat(outputFilePath)
 	if err == nil {
@@ -190,6 +436,68 @@ func SaveToOutputFile(outputFilePath, renderedFileContents string, overwriteFlag
 	return err
 }
 
+// FileBackup holds the contents of a file before an output is written to it,
+// so that the output can be rolled back. CreatedDir is the outermost directory
+// that will be created for the file, if any.
+type FileBackup struct {
+	Path       string
+	Existed    bool
+	Contents   []byte
+	CreatedDir string
+}
+
+func BackupFile(filePath string) (FileBackup, error) {
+	contents, err := os.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		createdDir, err := missingDir(filepath.Dir(filePath))
+		if err != nil {
+			return FileBackup{}, err
+		}
+		return FileBackup{Path: filePath, CreatedDir: createdDir}, nil
+	}
+	if err != nil {
+		return FileBackup{}, err
+	}
+	return FileBackup{Path: filePath, Existed: true, Contents: contents}, nil
+}
+
+// missingDir returns the outermost directory of dir that does not exist yet,
+// or "" when dir exists.
+func missingDir(dir string) (string, error) {
+	missing := ""
+	for {
+		_, err := os.Stat(dir)
+		if err == nil {
+			return missing, nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		missing = dir
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return missing, nil
+		}
+		dir = parent
+	}
+}
+
+// RestoreFile puts back the contents of a file, or removes it along with the
+// directories created for it.
+func RestoreFile(backup FileBackup) error {
+	if backup.Existed {
+		return os.WriteFile(backup.Path, backup.Contents, 0644)
+	}
+	err := os.Remove(backup.Path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if backup.CreatedDir != "" {
+		return os.RemoveAll(backup.CreatedDir)
+	}
+	return nil
+}
+
 func writeToExistingFile(outputFilePath, renderedFileContents string, overwriteFlag, appendFlag bool) error {
 	var overwrite *bool
 	flag := true
@@ -278,27 +586,48 @@ func PromptForUserInput(question Question) (string, error) {
 	var err error
 	if question.InputType == "select" {
 
+		items, options := selectItems(question)
+		size := question.PageSize
+		if size == 0 {
+			size = defaultSelectPageSize
+		}
 		prompt := promptui.Select{
-			Label: question.Prompt,
-			Items: question.ValidValues,
+			Label:    question.Prompt,
+			Items:    items,
+			Size:     size,
+			Searcher: searchItems(options),
+			Templates: &promptui.SelectTemplates{
+				Label:    "{{ . }}",
+				Active:   promptui.IconSelect + " {{ if .Header }}{{ .Header | bold }}  {{ end }}{{ .Label | underline }}{{ if .Description }} {{ .Description | faint }}{{ end }}",
+				Inactive: "  {{ if .Header }}{{ .Header | bold }}  {{ end }}{{ .Label }}{{ if .Description }} {{ .Description | faint }}{{ end }}",
+				Selected: promptui.IconGood + " {{ .Label | faint }}",
+			},
+		}
+		cursor := 0
+		for i, option := range options {
+			if option.String() == question.Default || option.Label == question.Default {
+				cursor = i
+			}
 		}
 
-		_, result, err = prompt.Run()
+		// Type / to search the options
+		index, _, err := prompt.RunCursorAt(cursor, cursor-size+1)
 
 		if err != nil {
 			return "", fmt.Errorf("Prompt failed %v\n", err)
 		}
+		result = options[index].String()
 	}
-	if question.InputType == "textfield" {
-		validate := func(input string) error {
-			if input == "" {
-				return errors.New("empty input")
-			}
-			return nil
+	validate := func(input string) error {
+		if input == "" {
+			return errors.New("empty input")
 		}
-
+		return nil
+	}
+	if question.InputType == "textfield" {
 		prompt := promptui.Prompt{
 			Label:    question.Prompt,
+			Default:  question.Default,
 			Validate: validate,
 		}
 
@@ -308,6 +637,37 @@ func PromptForUserInput(question Question) (string, error) {
 			return "", fmt.Errorf("Prompt failed %v\n", err)
 		}
 	}
+	if question.InputType == "password" {
+		for {
+			prompt := promptui.Prompt{
+				Label:    question.Prompt,
+				Mask:     '*',
+				Validate: validate,
+			}
+
+			result, err = prompt.Run()
+
+			if err != nil {
+				return "", fmt.Errorf("Prompt failed %v\n", err)
+			}
+			if !question.Confirm {
+				break
+			}
+
+			confirm := promptui.Prompt{
+				Label: "Confirm " + question.Prompt,
+				Mask:  '*',
+			}
+			confirmation, err := confirm.Run()
+			if err != nil {
+				return "", fmt.Errorf("Prompt failed %v\n", err)
+			}
+			if confirmation == result {
+				break
+			}
+			fmt.Println("The answers do not match, try again")
+		}
+	}
 
 	return result, nil
 }
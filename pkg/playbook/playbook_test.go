@@ -203,6 +203,54 @@ func getPlaybookTestData() []playbookTest {
 	empty_var_type.Questions = append(empty_var_type.Questions, question)
 	playbookTests = append(playbookTests, playbookTest{playbook: empty_var_type, playbook_base_dir: playbook_base_dir, wantErr: true})
 
+	var output_with_when = gke_cluster_playbook_data
+	output = output_with_when.Outputs[0]
+	output.When = `eq .cluster_name "prod"`
+	output_with_when.Outputs = []Output{output}
+	playbookTests = append(playbookTests, playbookTest{playbook: output_with_when, playbook_base_dir: playbook_base_dir, wantErr: false})
+
+	var output_with_invalid_when = gke_cluster_playbook_data
+	output = output_with_invalid_when.Outputs[0]
+	output.When = "{{ if .cluster_name }}"
+	output_with_invalid_when.Outputs = []Output{output}
+	playbookTests = append(playbookTests, playbookTest{playbook: output_with_invalid_when, playbook_base_dir: playbook_base_dir, wantErr: true})
+
+	var variable_named_like_question = gke_cluster_playbook_data
+	variable_named_like_question.Variables = Variables{"cluster_name": "{{ .cluster_location }}"}
+	playbookTests = append(playbookTests, playbookTest{playbook: variable_named_like_question, playbook_base_dir: playbook_base_dir, wantErr: true})
+
+	var variables_with_cycle = gke_cluster_playbook_data
+	variables_with_cycle.Variables = Variables{"a": "{{ .b }}", "b": "{{ .a }}"}
+	playbookTests = append(playbookTests, playbookTest{playbook: variables_with_cycle, playbook_base_dir: playbook_base_dir, wantErr: true})
+
+	var hook_without_command = gke_cluster_playbook_data
+	hook_without_command.Hooks = &Hooks{PostWrite: []Hook{{Args: []string{"fmt"}}}}
+	playbookTests = append(playbookTests, playbookTest{playbook: hook_without_command, playbook_base_dir: playbook_base_dir, wantErr: true})
+
+	var hook_with_invalid_timeout = gke_cluster_playbook_data
+	hook_with_invalid_timeout.Hooks = &Hooks{PostWrite: []Hook{{Command: "terraform", Args: []string{"fmt"}, Timeout: "soon"}}}
+	playbookTests = append(playbookTests, playbookTest{playbook: hook_with_invalid_timeout, playbook_base_dir: playbook_base_dir, wantErr: true})
+
+	var page_size_on_textfield = gke_cluster_playbook_data
+	question = page_size_on_textfield.Questions[0]
+	question.InputType = "textfield"
+	question.PageSize = 20
+	page_size_on_textfield.Questions = []Question{question}
+	playbookTests = append(playbookTests, playbookTest{playbook: page_size_on_textfield, playbook_base_dir: playbook_base_dir, wantErr: true})
+
+	var confirm_on_textfield = gke_cluster_playbook_data
+	question = confirm_on_textfield.Questions[0]
+	question.InputType = "textfield"
+	question.Confirm = true
+	confirm_on_textfield.Questions = []Question{question}
+	playbookTests = append(playbookTests, playbookTest{playbook: confirm_on_textfield, playbook_base_dir: playbook_base_dir, wantErr: true})
+
+	var invalid_env = gke_cluster_playbook_data
+	question = invalid_env.Questions[0]
+	question.Env = "TF-VAR-name"
+	invalid_env.Questions = []Question{question}
+	playbookTests = append(playbookTests, playbookTest{playbook: invalid_env, playbook_base_dir: playbook_base_dir, wantErr: true})
+
 	var zero_valid_values = gke_cluster_playbook_data
 	for i, _ := range zero_valid_values.Questions {
 		if zero_valid_values.Questions[i].ValidValues != nil && len(zero_valid_values.Questions[i].ValidValues) > 0 {
@@ -358,3 +406,95 @@ func TestRenderTemplate(t *testing.T) {
 		})
 	}
 }
+
+func TestEvaluateCondition(t *testing.T) {
+	input_data := map[string]interface{}{
+		"create_service_account": "true",
+		"enable_logging":         "false",
+		"cloud":                  "gcp",
+	}
+	tests := []struct {
+		name    string
+		when    string
+		want    bool
+		wantErr bool
+	}{
+		{name: "empty", when: "", want: true},
+		{name: "true_answer", when: ".create_service_account", want: true},
+		{name: "false_answer", when: ".enable_logging", want: false},
+		{name: "missing_answer", when: ".unknown", want: false},
+		{name: "expression", when: `eq .cloud "gcp"`, want: true},
+		{name: "template", when: `{{ if eq .cloud "aws" }}yes{{ end }}`, want: false},
+		{name: "not", when: "not (eq .enable_logging \"true\")", want: true},
+		{name: "invalid", when: "eq .cloud", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EvaluateCondition(tt.when, input_data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EvaluateCondition() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("EvaluateCondition() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateForEach(t *testing.T) {
+	questions := []Question{{VariableName: "environments"}}
+	tests := []struct {
+		name      string
+		questions []Question
+		variables Variables
+		forEach   string
+		wantErr   bool
+	}{
+		{name: "question", questions: questions, forEach: "environments"},
+		{name: "variable", variables: Variables{"regions": "us-east1,europe-west1"}, forEach: "regions"},
+		{name: "unknown", questions: questions, forEach: "teams", wantErr: true},
+		{name: "item_question", questions: append(questions, Question{VariableName: "item"}), forEach: "environments", wantErr: true},
+		{name: "index_variable", questions: questions, variables: Variables{"index": "1"}, forEach: "environments", wantErr: true},
+		{name: "item_without_forEach", questions: append(questions, Question{VariableName: "item"})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			playbook := Playbook{Questions: tt.questions, Variables: tt.variables, Outputs: []Output{{OutputFile: "{{.item}}.tf", ForEach: tt.forEach}}}
+			if err := validateForEach(playbook); (err != nil) != tt.wantErr {
+				t.Errorf("validateForEach() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestForEachItems(t *testing.T) {
+	input_data := map[string]interface{}{
+		"environments": "dev, staging,\nprod,",
+		"regions":      []interface{}{"us-east1", "europe-west1"},
+		"users":        []string{"ana"},
+		"count":        3,
+	}
+	tests := []struct {
+		name     string
+		variable string
+		want     []interface{}
+		wantErr  bool
+	}{
+		{name: "string", variable: "environments", want: []interface{}{"dev", "staging", "prod"}},
+		{name: "list", variable: "regions", want: []interface{}{"us-east1", "europe-west1"}},
+		{name: "string_slice", variable: "users", want: []interface{}{"ana"}},
+		{name: "not_a_list", variable: "count", wantErr: true},
+		{name: "missing", variable: "teams", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ForEachItems(input_data, tt.variable)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ForEachItems() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ForEachItems() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
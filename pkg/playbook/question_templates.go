@@ -0,0 +1,170 @@
+/*
+Copyright (c) 2023 Peach Pie Labs, LLC.
+*/
+
+package playbook
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RenderQuestion renders the Prompt, Placeholder, Default and ValidValues of a
+// question, with the labels, descriptions and groups of its options, the
+// glob, file and path of its OptionsFrom, and its OptionsCommand, as
+// templates with the answers collected so far, so that a default such as
+// {{.cluster_name}}-sa follows an earlier answer. Valid values that render to
+// an empty string are dropped.
+func RenderQuestion(question Question, input_data map[string]interface{}) (Question, error) {
+	var err error
+	fields := []*string{&question.Prompt, &question.Placeholder, &question.Default}
+	if question.OptionsFrom != nil {
+		options := *question.OptionsFrom
+		question.OptionsFrom = &options
+		fields = append(fields, &options.Glob, &options.File, &options.Path)
+	}
+	if question.OptionsCommand != nil {
+		command := *question.OptionsCommand
+		command.Args = append([]string{}, command.Args...)
+		question.OptionsCommand = &command
+		fields = append(fields, &command.Command, &command.Dir)
+		for i := range command.Args {
+			fields = append(fields, &command.Args[i])
+		}
+	}
+	for _, field := range fields {
+		*field, err = RenderText(*field, input_data)
+		if err != nil {
+			return question, fmt.Errorf("question %s: %w", question.VariableName, err)
+		}
+	}
+	if question.ValidValues != nil {
+		hasOptions := question.hasOptions()
+		validValues := make([]string, 0, len(question.ValidValues))
+		var options []SelectOption
+		for i, value := range question.ValidValues {
+			value, err = RenderText(value, input_data)
+			if err != nil {
+				return question, fmt.Errorf("question %s: %w", question.VariableName, err)
+			}
+			if value == "" {
+				continue
+			}
+			validValues = append(validValues, value)
+			if hasOptions {
+				option := question.Options[i]
+				if _, ok := option.Value.(string); ok {
+					option.Value = value
+				}
+				for _, field := range []*string{&option.Label, &option.Description, &option.Group} {
+					*field, err = RenderText(*field, input_data)
+					if err != nil {
+						return question, fmt.Errorf("question %s: %w", question.VariableName, err)
+					}
+				}
+				options = append(options, option)
+			}
+		}
+		question.ValidValues = validValues
+		if hasOptions {
+			question.Options = options
+		}
+	}
+	return question, nil
+}
+
+// validateQuestionTemplates checks that the templates of every question only
+// refer to the answers of earlier questions, and to variables computed from them.
+func validateQuestionTemplates(playbook Playbook) error {
+	// The questions each variable waits for, directly or through other variables
+	variableQuestions := make(map[string]map[string]bool)
+	questionNames := make(map[string]bool)
+	for _, question := range playbook.Questions {
+		questionNames[question.VariableName] = true
+	}
+	var collect func(name string, seen map[string]bool) (map[string]bool, error)
+	collect = func(name string, seen map[string]bool) (map[string]bool, error) {
+		if questions, ok := variableQuestions[name]; ok {
+			return questions, nil
+		}
+		if seen[name] {
+			// Cycles are reported by validateVariables
+			return map[string]bool{}, nil
+		}
+		seen[name] = true
+		fields, err := templateFields(playbook.Variables[name])
+		if err != nil {
+			return nil, fmt.Errorf("invalid variable %s: %w", name, err)
+		}
+		questions := make(map[string]bool)
+		for _, field := range fields {
+			if questionNames[field] {
+				questions[field] = true
+			} else if _, ok := playbook.Variables[field]; ok {
+				dependencies, err := collect(field, seen)
+				if err != nil {
+					return nil, err
+				}
+				for question := range dependencies {
+					questions[question] = true
+				}
+			}
+		}
+		variableQuestions[name] = questions
+		return questions, nil
+	}
+
+	answered := make(map[string]bool)
+	for _, question := range playbook.Questions {
+		templates := map[string][]string{
+			"prompt":      {question.Prompt},
+			"placeholder": {question.Placeholder},
+			"default":     {question.Default},
+			"validValues": question.ValidValues,
+		}
+		for _, option := range question.Options {
+			templates["validValues"] = append(templates["validValues"], option.Label, option.Description, option.Group)
+		}
+		if question.OptionsFrom != nil {
+			templates["optionsFrom"] = []string{question.OptionsFrom.Glob, question.OptionsFrom.File, question.OptionsFrom.Path}
+		}
+		if question.OptionsCommand != nil {
+			templates["optionsCommand"] = append([]string{question.OptionsCommand.Command, question.OptionsCommand.Dir}, question.OptionsCommand.Args...)
+		}
+		for _, field := range []string{"prompt", "placeholder", "default", "validValues", "optionsFrom", "optionsCommand"} {
+			for _, text := range templates[field] {
+				if !strings.Contains(text, "{{") {
+					continue
+				}
+				names, err := templateFields(text)
+				if err != nil {
+					return fmt.Errorf("invalid %s template for question %s. %w", field, question.VariableName, err)
+				}
+				for _, name := range names {
+					if answered[name] {
+						continue
+					}
+					if _, ok := playbook.Variables[name]; ok {
+						questions, err := collect(name, map[string]bool{})
+						if err != nil {
+							return err
+						}
+						ready := true
+						for dependency := range questions {
+							if !answered[dependency] {
+								ready = false
+							}
+						}
+						if ready {
+							continue
+						}
+						return fmt.Errorf("%s of question %s uses variable %s, which depends on answers to later questions", field, question.VariableName, name)
+					}
+					return fmt.Errorf("%s of question %s uses %s, which is not the answer to an earlier question", field, question.VariableName, name)
+				}
+			}
+		}
+		answered[question.VariableName] = true
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+/*
+Copyright (c) 2023 Peach Pie Labs, LLC.
+*/
+
+package playbook
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRenderQuestion(t *testing.T) {
+	question := Question{
+		Prompt:       "Service account for {{.cluster_name}}",
+		Placeholder:  "{{.cluster_name}}-sa",
+		VariableName: "service_account_id",
+		Default:      "{{.cluster_name}}-sa",
+		ValidValues:  []string{"{{.cluster_name}}-sa", "default", `{{ if eq .environment "prod" }}{{.cluster_name}}-prod-sa{{ end }}`},
+	}
+	input_data := map[string]interface{}{"cluster_name": "web", "environment": "dev"}
+
+	got, err := RenderQuestion(question, input_data)
+	if err != nil {
+		t.Fatalf("RenderQuestion() error = %v", err)
+	}
+	want := Question{
+		Prompt:       "Service account for web",
+		Placeholder:  "web-sa",
+		VariableName: "service_account_id",
+		Default:      "web-sa",
+		ValidValues:  []string{"web-sa", "default"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("RenderQuestion() = %+v, want %+v", got, want)
+	}
+	if question.ValidValues[0] != "{{.cluster_name}}-sa" {
+		t.Errorf("RenderQuestion() modified the valid values of the question")
+	}
+}
+
+func TestValidateQuestionTemplates(t *testing.T) {
+	questions := []Question{
+		{VariableName: "cluster_name", Prompt: "Cluster name"},
+		{VariableName: "environment", Prompt: "Environment"},
+	}
+	tests := []struct {
+		name      string
+		question  Question
+		variables Variables
+		wantErr   bool
+	}{
+		{
+			name:     "earlier_answer",
+			question: Question{VariableName: "service_account_id", Prompt: "Service account", Default: "{{.cluster_name}}-sa"},
+		},
+		{
+			name:      "variable_of_earlier_answers",
+			question:  Question{VariableName: "bucket", Prompt: "Bucket for {{.slug}}", ValidValues: []string{"{{.slug}}-logs"}},
+			variables: Variables{"slug": "{{.cluster_name | lower}}-{{.environment}}"},
+		},
+		{
+			name:     "later_answer",
+			question: Question{VariableName: "service_account_id", Prompt: "Service account", Default: "{{.project}}-sa"},
+			wantErr:  true,
+		},
+		{
+			name:      "variable_of_later_answer",
+			question:  Question{VariableName: "bucket", Prompt: "Bucket", Placeholder: "{{.slug}}"},
+			variables: Variables{"slug": "{{.service_account_id}}"},
+			wantErr:   true,
+		},
+		{
+			name:     "own_answer",
+			question: Question{VariableName: "service_account_id", Prompt: "Service account", Default: "{{.service_account_id}}"},
+			wantErr:  true,
+		},
+		{
+			name:     "invalid_template",
+			question: Question{VariableName: "service_account_id", Prompt: "Service account {{.cluster_name"},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			playbook := Playbook{
+				Questions: append(append([]Question{}, questions...), tt.question, Question{VariableName: "service_account_id", Prompt: "Later"}),
+				Variables: tt.variables,
+			}
+			err := validateQuestionTemplates(playbook)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateQuestionTemplates() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
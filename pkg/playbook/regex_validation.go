@@ -1,14 +1,46 @@
 package playbook
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
+	"math"
 	"net"
 	"net/mail"
 	"net/url"
 	"regexp"
 	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 )
 
+// ValidateAnswer checks a value against the validation configured on the
+// question, whether it was entered at the prompt or provided another way.
+func ValidateAnswer(value string, question Question) error {
+	if question.InputType == "select" && len(question.ValidValues) > 0 {
+		valid := false
+		for _, validValue := range question.ValidValues {
+			if value == validValue {
+				valid = true
+			}
+		}
+		if !valid {
+			return fmt.Errorf("%q is not a valid value for %s. valid values are %v", value, question.VariableName, question.ValidValues)
+		}
+	}
+	if question.CustomRegexValidation != "" {
+		if err := CustomRegexValidate(value, question.CustomRegexValidation); err != nil {
+			return err
+		}
+	} else if question.Validation != "" {
+		if err := RegexPatternValidate(value, question); err != nil {
+			return err
+		}
+	}
+	return checkValidationRules(value, question.Validations)
+}
+
 func CustomRegexValidate(value, pattern string) error {
 	matched, err := regexp.MatchString(pattern, value)
 	if err != nil {
@@ -21,71 +53,91 @@ func CustomRegexValidate(value, pattern string) error {
 	return nil
 }
 
+// RegexPatternValidate checks a value with the validator registered under the
+// name of the question's validation.
 func RegexPatternValidate(value string, question Question) error {
-	var flag bool
-
-	if question.Validation == "domain_name" {
-		flag = validateDomain(value)
-	} else if question.Validation == "ip_address" {
-		flag = validateIP(value)
-	} else if question.Validation == "email" {
-		flag = validateEmail(value)
-	} else if question.Validation == "url" {
-		var isAny, isHttps, isHttp bool
-		var schema string
-		for _, valildPattern := range question.ValidPatterns {
-			if valildPattern == "any" {
-				isAny = true
-			} else if valildPattern == "https" {
-				isHttps = true
-			} else if valildPattern == "http" {
-				isHttp = true
-			} else {
-				return errors.New("invalid value found in the place of validPatterns field")
-			}
+	validator, ok := lookupValidator(question.Validation)
+	if !ok {
+		return errors.New("invalid pattern name for validation, provide valid value")
+	}
+	return validator.Validate(value, question)
+}
+
+// checkFlag turns a check of a value into a validation reporting the generic
+// error of the original validations.
+func checkFlag(check func(value string) bool) func(value string, question Question) error {
+	return func(value string, question Question) error {
+		if !check(value) {
+			return errors.New("regex pattern validation failed")
 		}
-		if isAny || (isHttp && isHttps) {
-			schema = "any"
-		} else if isHttp {
-			schema = "http"
-		} else if isHttps {
-			schema = "https"
+		return nil
+	}
+}
+
+func validateURLValue(value string, question Question) error {
+	var isAny, isHttps, isHttp bool
+	var schema string
+	for _, valildPattern := range question.ValidPatterns {
+		if valildPattern == "any" {
+			isAny = true
+		} else if valildPattern == "https" {
+			isHttps = true
+		} else if valildPattern == "http" {
+			isHttp = true
 		} else {
-			return errors.New("found no value of validPattern")
-		}
-		flag = validateURL(value, schema)
-	} else if question.Validation == "integer_range" {
-		if question.Range == nil {
-			return errors.New("for integer_range the range field is necessary")
+			return errors.New("invalid value found in the place of validPatterns field")
 		}
+	}
+	if isAny || (isHttp && isHttps) {
+		schema = "any"
+	} else if isHttp {
+		schema = "http"
+	} else if isHttps {
+		schema = "https"
+	} else {
+		return errors.New("found no value of validPattern")
+	}
+	if !validateURL(value, schema) {
+		return errors.New("regex pattern validation failed")
+	}
+	return nil
+}
 
-		if question.Range.Min == nil || question.Range.Max == nil {
-			return errors.New("for integer_range min and max requied")
-		}
-		mn := *question.Range.Min
-		mx := *question.Range.Max
+func validateIntegerRangeValue(value string, question Question) error {
+	if question.Range == nil {
+		return errors.New("for integer_range the range field is necessary")
+	}
 
-		if mn > mx {
-			return errors.New("min cannot be greater than max")
-		}
+	if question.Range.Min == nil || question.Range.Max == nil {
+		return errors.New("for integer_range min and max requied")
+	}
+	mn := *question.Range.Min
+	mx := *question.Range.Max
 
-		intValue, err := strconv.Atoi(value)
-		if err != nil {
-			return err
-		}
+	if mn > mx {
+		return errors.New("min cannot be greater than max")
+	}
 
-		flag = validateIntegerRange(intValue, mn, mx)
-	} else {
-		return errors.New("invalid pattern name for validation, provide valid value")
+	intValue, err := strconv.Atoi(value)
+	if err != nil {
+		return err
 	}
 
-	if !flag {
+	if !validateIntegerRange(intValue, mn, mx) {
 		return errors.New("regex pattern validation failed")
 	}
-
 	return nil
 }
 
+func validateOneOf(value string, question Question) error {
+	for _, validValue := range question.ValidValues {
+		if value == validValue {
+			return nil
+		}
+	}
+	return fmt.Errorf("%q is not one of %v", value, question.ValidValues)
+}
+
 func validateEmail(email string) bool {
 	_, err := mail.ParseAddress(email)
 	return err == nil
@@ -118,3 +170,139 @@ func validateURL(rawurl string, schema string) bool {
 func validateIntegerRange(number, min, max int) bool {
 	return number >= min && number <= max
 }
+
+var (
+	semverPattern    = regexp.MustCompile(`^(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(?:-((?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\.(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?(?:\+([0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?$`)
+	uuidPattern      = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	portPattern      = regexp.MustCompile(`^[1-9][0-9]*$`)
+	hostnameLabel    = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?$`)
+	arnPattern       = regexp.MustCompile(`^arn:([a-z0-9-]+):([a-z0-9-]+):([a-z0-9-]*):([0-9]{12}|aws)?:(.+)$`)
+	gcpRegionPattern = regexp.MustCompile(`^[a-z]+-[a-z]+[0-9]+$`)
+	awsRegionPattern = regexp.MustCompile(`^(us|eu|ap|sa|ca|me|af|il|mx|cn)(-gov|-iso|-isob)?-(north|south|east|west|central|northeast|northwest|southeast|southwest)-[0-9]+$`)
+)
+
+func validatePattern(pattern *regexp.Regexp, description string) func(value string, question Question) error {
+	return func(value string, question Question) error {
+		if !pattern.MatchString(value) {
+			return fmt.Errorf("%q is not %s", value, description)
+		}
+		return nil
+	}
+}
+
+// checkRange checks a number against the range of a question, when it has one.
+func checkRange(number int, bounds *IntegerRange, description string) error {
+	if bounds == nil {
+		return nil
+	}
+	if bounds.Min != nil && number < *bounds.Min {
+		return fmt.Errorf("%s must be at least %d", description, *bounds.Min)
+	}
+	if bounds.Max != nil && number > *bounds.Max {
+		return fmt.Errorf("%s must be at most %d", description, *bounds.Max)
+	}
+	return nil
+}
+
+// validateCIDR accepts IPv4 and IPv6 networks, or only one of them with
+// validPatterns ipv4 or ipv6, and checks the prefix length against the range.
+func validateCIDR(value string, question Question) error {
+	_, network, err := net.ParseCIDR(value)
+	if err != nil {
+		return fmt.Errorf("%q is not a CIDR block, e.g. 10.0.0.0/16", value)
+	}
+	// The mask tells the version, as IPv4-mapped IPv6 blocks like
+	// ::ffff:10.0.0.0/104 have an IPv4 address
+	version := "ipv6"
+	if len(network.Mask) == net.IPv4len {
+		version = "ipv4"
+	}
+	if len(question.ValidPatterns) > 0 {
+		allowed := false
+		for _, pattern := range question.ValidPatterns {
+			if pattern == "any" || pattern == version {
+				allowed = true
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("%q is an %s CIDR block, expected %s", value, version, strings.Join(question.ValidPatterns, " or "))
+		}
+	}
+	prefix, _ := network.Mask.Size()
+	return checkRange(prefix, question.Range, "the prefix length of "+value)
+}
+
+func validatePort(value string, question Question) error {
+	port, err := strconv.Atoi(value)
+	if !portPattern.MatchString(value) || err != nil || port > 65535 {
+		return fmt.Errorf("%q is not a port number between 1 and 65535", value)
+	}
+	return checkRange(port, question.Range, "the port")
+}
+
+// validateHostname checks that every label of a host name follows RFC 1123.
+func validateHostname(value string, question Question) error {
+	if len(value) > 253 {
+		return fmt.Errorf("%q is longer than 253 characters", value)
+	}
+	for _, label := range strings.Split(value, ".") {
+		if !hostnameLabel.MatchString(label) {
+			return fmt.Errorf("%q is not a host name. every label must be 1 to 63 letters, digits or hyphens, and cannot start or end with a hyphen", value)
+		}
+	}
+	return nil
+}
+
+func validateDuration(value string, question Question) error {
+	if _, err := time.ParseDuration(value); err != nil {
+		return fmt.Errorf("%q is not a duration, e.g. 90s or 1h30m", value)
+	}
+	return nil
+}
+
+func validateFloatRange(value string, question Question) error {
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+		return fmt.Errorf("%q is not a number", value)
+	}
+	if question.FloatRange == nil {
+		return errors.New("for float_range the floatRange field is necessary")
+	}
+	if question.FloatRange.Min != nil && number < *question.FloatRange.Min {
+		return fmt.Errorf("%v must be at least %v", value, *question.FloatRange.Min)
+	}
+	if question.FloatRange.Max != nil && number > *question.FloatRange.Max {
+		return fmt.Errorf("%v must be at most %v", value, *question.FloatRange.Max)
+	}
+	return nil
+}
+
+// validateLength checks the number of characters of a value against the range.
+func validateLength(value string, question Question) error {
+	return checkRange(utf8.RuneCountInString(value), question.Range, "the length")
+}
+
+func validateJSONValue(value string, question Question) error {
+	if !json.Valid([]byte(value)) {
+		return fmt.Errorf("%q is not valid JSON", value)
+	}
+	return nil
+}
+
+// validateARN checks identifiers shaped like arn:partition:service:region:account:resource,
+// and restricts the service to the validPatterns when given.
+func validateARN(value string, question Question) error {
+	match := arnPattern.FindStringSubmatch(value)
+	if match == nil {
+		return fmt.Errorf("%q is not an ARN, e.g. arn:aws:iam::123456789012:role/deploy", value)
+	}
+	if len(question.ValidPatterns) > 0 {
+		for _, service := range question.ValidPatterns {
+			if match[2] == service {
+				return nil
+			}
+		}
+		return fmt.Errorf("%q is an ARN of service %s, expected %s", value, match[2], strings.Join(question.ValidPatterns, " or "))
+	}
+	return nil
+}
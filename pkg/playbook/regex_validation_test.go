@@ -330,3 +330,60 @@ func TestRegexPatternValidate(t *testing.T) {
 		})
 	}
 }
+
+func TestBuiltinValidators(t *testing.T) {
+	intPointer := func(n int) *int { return &n }
+	floatPointer := func(n float64) *float64 { return &n }
+	tests := []struct {
+		name     string
+		value    string
+		question Question
+		wantErr  bool
+	}{
+		{name: "cidr_ipv4", value: "10.0.0.0/16", question: Question{Validation: "cidr"}},
+		{name: "cidr_ipv6", value: "2001:db8::/32", question: Question{Validation: "cidr"}},
+		{name: "cidr_without_prefix", value: "10.0.0.0", question: Question{Validation: "cidr"}, wantErr: true},
+		{name: "cidr_wrong_version", value: "2001:db8::/32", question: Question{Validation: "cidr", ValidPatterns: []string{"ipv4"}}, wantErr: true},
+		{name: "cidr_ipv4_mapped", value: "::ffff:10.0.0.0/104", question: Question{Validation: "cidr", ValidPatterns: []string{"ipv4"}}, wantErr: true},
+		{name: "cidr_prefix_in_range", value: "10.0.0.0/20", question: Question{Validation: "cidr", Range: &IntegerRange{Min: intPointer(16), Max: intPointer(24)}}},
+		{name: "cidr_prefix_out_of_range", value: "10.0.0.0/8", question: Question{Validation: "cidr", Range: &IntegerRange{Min: intPointer(16), Max: intPointer(24)}}, wantErr: true},
+		{name: "port", value: "8080", question: Question{Validation: "port"}},
+		{name: "port_zero", value: "0", question: Question{Validation: "port"}, wantErr: true},
+		{name: "port_sign", value: "+443", question: Question{Validation: "port"}, wantErr: true},
+		{name: "port_leading_zero", value: "0080", question: Question{Validation: "port"}, wantErr: true},
+		{name: "port_out_of_range", value: "80", question: Question{Validation: "port", Range: &IntegerRange{Min: intPointer(1024)}}, wantErr: true},
+		{name: "hostname", value: "web-1.internal", question: Question{Validation: "hostname"}},
+		{name: "hostname_leading_hyphen", value: "-web", question: Question{Validation: "hostname"}, wantErr: true},
+		{name: "hostname_empty_label", value: "web..internal", question: Question{Validation: "hostname"}, wantErr: true},
+		{name: "semver", value: "1.2.3-rc.1+build.5", question: Question{Validation: "semver"}},
+		{name: "semver_leading_zero", value: "01.2.3", question: Question{Validation: "semver"}, wantErr: true},
+		{name: "uuid", value: "123e4567-e89b-12d3-a456-426614174000", question: Question{Validation: "uuid"}},
+		{name: "uuid_short", value: "123e4567-e89b-12d3-a456", question: Question{Validation: "uuid"}, wantErr: true},
+		{name: "duration", value: "1h30m", question: Question{Validation: "duration"}},
+		{name: "duration_without_unit", value: "90", question: Question{Validation: "duration"}, wantErr: true},
+		{name: "float_range", value: "0.75", question: Question{Validation: "float_range", FloatRange: &FloatRange{Min: floatPointer(0), Max: floatPointer(1)}}},
+		{name: "float_range_above", value: "1.5", question: Question{Validation: "float_range", FloatRange: &FloatRange{Min: floatPointer(0), Max: floatPointer(1)}}, wantErr: true},
+		{name: "float_range_nan", value: "NaN", question: Question{Validation: "float_range", FloatRange: &FloatRange{Min: floatPointer(0), Max: floatPointer(1)}}, wantErr: true},
+		{name: "float_range_inf", value: "+Inf", question: Question{Validation: "float_range", FloatRange: &FloatRange{Min: floatPointer(0)}}, wantErr: true},
+		{name: "length", value: "héllo", question: Question{Validation: "length", Range: &IntegerRange{Max: intPointer(5)}}},
+		{name: "length_too_long", value: "hello!", question: Question{Validation: "length", Range: &IntegerRange{Max: intPointer(5)}}, wantErr: true},
+		{name: "json", value: `{"replicas": 3}`, question: Question{Validation: "json"}},
+		{name: "json_invalid", value: `{replicas: 3}`, question: Question{Validation: "json"}, wantErr: true},
+		{name: "arn", value: "arn:aws:iam::123456789012:role/deploy", question: Question{Validation: "arn"}},
+		{name: "arn_service", value: "arn:aws:s3:::my-bucket", question: Question{Validation: "arn", ValidPatterns: []string{"iam"}}, wantErr: true},
+		{name: "arn_invalid", value: "aws:iam::role/deploy", question: Question{Validation: "arn"}, wantErr: true},
+		{name: "gcp_region", value: "northamerica-northeast1", question: Question{Validation: "gcp_region"}},
+		{name: "gcp_zone", value: "us-central1-a", question: Question{Validation: "gcp_region"}, wantErr: true},
+		{name: "aws_region", value: "us-gov-west-1", question: Question{Validation: "aws_region"}},
+		{name: "aws_region_invalid", value: "us-east1", question: Question{Validation: "aws_region"}, wantErr: true},
+		{name: "one_of", value: "staging", question: Question{Validation: "one_of", ValidValues: []string{"dev", "staging"}}},
+		{name: "one_of_invalid", value: "prod", question: Question{Validation: "one_of", ValidValues: []string{"dev", "staging"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := RegexPatternValidate(tt.value, tt.question); (err != nil) != tt.wantErr {
+				t.Errorf("RegexPatternValidate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+/*
+Copyright (c) 2023 Peach Pie Labs, LLC.
+*/
+
+package playbook
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	RemoveStatusDeleted  = "deleted"
+	RemoveStatusStripped = "stripped"
+	RemoveStatusKept     = "kept"
+	RemoveStatusMissing  = "missing"
+)
+
+type RemoveResult struct {
+	OutputFile string
+	Status     string
+	Reason     string
+}
+
+// RemoveGeneration undoes a generation: files it created are deleted and
+// blocks it appended to existing files are stripped. Files that existed before
+// the generation and were overwritten are kept. Unless force is set, nothing
+// is removed when any of the files was modified since the generation.
+func RemoveGeneration(root string, generation Generation, force bool) ([]RemoveResult, error) {
+	var modified []string
+	for _, file := range generation.Files {
+		currentContents, err := os.ReadFile(filepath.Join(root, file.OutputFile))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch file.Mode {
+		case OutputModeCreate:
+			if Checksum(string(currentContents)) != file.Checksum {
+				modified = append(modified, file.OutputFile)
+			}
+		case OutputModeAppend:
+			block, err := appendedBlock(generation, file)
+			if err != nil {
+				return nil, err
+			}
+			if block == "" || !strings.Contains(string(currentContents), block) {
+				modified = append(modified, file.OutputFile)
+			}
+		}
+	}
+	if len(modified) > 0 && !force {
+		return nil, fmt.Errorf("files were modified since generation %s: %s. Use --force to remove them anyway", generation.ID, strings.Join(modified, ", "))
+	}
+
+	var results []RemoveResult
+	for _, file := range generation.Files {
+		result := RemoveResult{OutputFile: file.OutputFile}
+		outputFilePath := filepath.Join(root, file.OutputFile)
+		currentContents, err := os.ReadFile(outputFilePath)
+		if os.IsNotExist(err) {
+			result.Status = RemoveStatusMissing
+			results = append(results, result)
+			continue
+		}
+		if err != nil {
+			return results, err
+		}
+
+		switch file.Mode {
+		case OutputModeCreate:
+			err = os.Remove(outputFilePath)
+			if err != nil {
+				return results, err
+			}
+			removeEmptyDirs(root, filepath.Dir(outputFilePath))
+			result.Status = RemoveStatusDeleted
+		case OutputModeAppend:
+			block, err := appendedBlock(generation, file)
+			if err != nil {
+				return results, err
+			}
+			start := strings.LastIndex(string(currentContents), block)
+			if block == "" || start < 0 {
+				result.Status = RemoveStatusKept
+				result.Reason = "appended block was modified"
+				break
+			}
+			err = overwriteToFile(outputFilePath, string(currentContents)[:start]+string(currentContents)[start+len(block):])
+			if err != nil {
+				return results, err
+			}
+			result.Status = RemoveStatusStripped
+		default:
+			result.Status = RemoveStatusKept
+			result.Reason = "file existed before the generation"
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// appendedBlock renders the block an appended output added to its file again
+// from the recorded template. It returns an empty string when the rendered block
+// does not match the recorded checksum.
+func appendedBlock(generation Generation, file GeneratedFile) (string, error) {
+	block, err := renderGeneratedFile(generation, file, file.Template)
+	if err != nil {
+		return "", err
+	}
+	if Checksum(block) != file.Checksum {
+		return "", nil
+	}
+	return block, nil
+}
+
+// removeEmptyDirs deletes dir and its parents up to root for as long as they are empty.
+func removeEmptyDirs(root, dir string) {
+	for {
+		relativePath, err := filepath.Rel(root, dir)
+		if err != nil || relativePath == "." || strings.HasPrefix(relativePath, "..") {
+			return
+		}
+		if os.Remove(dir) != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
@@ -0,0 +1,65 @@
+/*
+Copyright (c) 2023 Peach Pie Labs, LLC.
+*/
+
+package playbook
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRemoveGeneration(t *testing.T) {
+	root := t.TempDir()
+	template_source := "record \"{{.name}}\"\n"
+	rendered := "record \"www\"\n"
+	err := os.MkdirAll(filepath.Join(root, "terraform"), os.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(root, "terraform", "www.tf"), []byte(rendered), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(root, "records.tf"), []byte("record \"api\"\n"+rendered), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	generation := NewGeneration("playbook.yaml", map[string]interface{}{"name": "www"}, nil)
+	generation.Files = []GeneratedFile{
+		{TemplateFile: "record.tpl", OutputFile: "terraform/www.tf", Template: template_source, Checksum: Checksum(rendered), Mode: OutputModeCreate},
+		{TemplateFile: "record.tpl", OutputFile: "records.tf", Template: template_source, Checksum: Checksum(rendered), Mode: OutputModeAppend},
+	}
+
+	// A modified file makes the removal fail unless forced
+	err = os.WriteFile(filepath.Join(root, "terraform", "www.tf"), []byte(rendered+"# edited\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = RemoveGeneration(root, generation, false); err == nil {
+		t.Fatalf("RemoveGeneration() wanted error for a modified file")
+	}
+	if _, err = os.Stat(filepath.Join(root, "terraform", "www.tf")); err != nil {
+		t.Fatalf("RemoveGeneration() deleted a file without force")
+	}
+
+	results, err := RemoveGeneration(root, generation, true)
+	if err != nil {
+		t.Fatalf("RemoveGeneration() error = %v", err)
+	}
+	if results[0].Status != RemoveStatusDeleted || results[1].Status != RemoveStatusStripped {
+		t.Errorf("RemoveGeneration() results = %v", results)
+	}
+	if _, err = os.Stat(filepath.Join(root, "terraform")); !os.IsNotExist(err) {
+		t.Errorf("RemoveGeneration() did not remove the empty directory")
+	}
+	got, err := os.ReadFile(filepath.Join(root, "records.tf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "record \"api\"\n" {
+		t.Errorf("RemoveGeneration() left %q", got)
+	}
+}
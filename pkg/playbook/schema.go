@@ -0,0 +1,225 @@
+/*
+Copyright (c) 2023 Peach Pie Labs, LLC.
+*/
+
+package playbook
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Schema is a JSON Schema loaded from a JSON or YAML file. Schemas without a
+// $schema keyword are read as draft 2020-12. References may point inside the
+// schema or to other schema files relative to it, and format is treated as an
+// annotation.
+type Schema struct {
+	file   string
+	schema *jsonschema.Schema
+}
+
+// SchemaViolation is a part of a document that does not match the schema.
+// Path is the JSON pointer of the offending value. Document is the 1-based
+// position of the document in a multi-document YAML stream, and 0 otherwise.
+type SchemaViolation struct {
+	Document int
+	Path     string
+	Message  string
+}
+
+func (v SchemaViolation) String() string {
+	path := v.Path
+	if path == "" {
+		path = "/"
+	}
+	if v.Document > 0 {
+		return fmt.Sprintf("document %d %s: %s", v.Document, path, v.Message)
+	}
+	return fmt.Sprintf("%s: %s", path, v.Message)
+}
+
+type SchemaError struct {
+	Schema     string
+	Violations []SchemaViolation
+}
+
+func (e *SchemaError) Error() string {
+	var message strings.Builder
+	message.WriteString(fmt.Sprintf("does not match schema %s:", e.Schema))
+	for _, violation := range e.Violations {
+		message.WriteString("\n  " + violation.String())
+	}
+	return message.String()
+}
+
+var schemaMessages = message.NewPrinter(language.English)
+
+// schemaLoader reads the schema files referenced by a schema, in JSON or YAML.
+type schemaLoader struct{}
+
+func (schemaLoader) Load(url string) (interface{}, error) {
+	file, err := jsonschema.FileLoader{}.ToFile(url)
+	if err != nil {
+		return nil, err
+	}
+	contents, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(filepath.Ext(file), ".json") {
+		return jsonschema.UnmarshalJSON(bytes.NewReader(contents))
+	}
+	var document interface{}
+	if err := yamlv3.Unmarshal(contents, &document); err != nil {
+		return nil, err
+	}
+	return jsonValue(document), nil
+}
+
+// LoadSchema reads a schema file and every schema file it references, and
+// checks that the schemas are valid and all references can be resolved.
+func LoadSchema(schema_path string) (*Schema, error) {
+	file, err := filepath.Abs(schema_path)
+	if err != nil {
+		return nil, err
+	}
+	compiler := jsonschema.NewCompiler()
+	compiler.UseLoader(schemaLoader{})
+	compiled, err := compiler.Compile(file)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema %s: %w", schema_path, err)
+	}
+	return &Schema{file: filepath.Clean(schema_path), schema: compiled}, nil
+}
+
+// Validate checks rendered contents against the schema. JSON documents and
+// YAML streams of one or more documents are accepted. It returns a
+// *SchemaError listing every violation.
+func (s *Schema) Validate(contents string) error {
+	documents, err := schemaDocuments(contents)
+	if err != nil {
+		return err
+	}
+	var violations []SchemaViolation
+	for i, document := range documents {
+		err := s.schema.Validate(document)
+		var validationErr *jsonschema.ValidationError
+		if errors.As(err, &validationErr) {
+			documentViolations := schemaViolations(validationErr)
+			sort.SliceStable(documentViolations, func(i, j int) bool {
+				return documentViolations[i].Path < documentViolations[j].Path
+			})
+			for _, violation := range documentViolations {
+				if len(documents) > 1 {
+					violation.Document = i + 1
+				}
+				violations = append(violations, violation)
+			}
+		} else if err != nil {
+			return err
+		}
+	}
+	if len(violations) > 0 {
+		return &SchemaError{Schema: filepath.ToSlash(s.file), Violations: violations}
+	}
+	return nil
+}
+
+// schemaViolations lists the failed keywords of a validation error.
+func schemaViolations(err *jsonschema.ValidationError) []SchemaViolation {
+	if len(err.Causes) == 0 {
+		path := ""
+		for _, token := range err.InstanceLocation {
+			path = jsonPointer(path, token)
+		}
+		return []SchemaViolation{{Path: path, Message: err.ErrorKind.LocalizedString(schemaMessages)}}
+	}
+	var violations []SchemaViolation
+	for _, cause := range err.Causes {
+		violations = append(violations, schemaViolations(cause)...)
+	}
+	return violations
+}
+
+// ValidateSchema loads the schema at schema_file, relative to
+// playbook_base_dir, and validates rendered contents against it.
+func ValidateSchema(playbook_base_dir string, schema_file string, contents string) error {
+	schema, err := LoadSchema(filepath.Join(playbook_base_dir, schema_file))
+	if err != nil {
+		return err
+	}
+	return schema.Validate(contents)
+}
+
+// schemaDocuments decodes rendered JSON, or a YAML stream. YAML is read with
+// the YAML 1.2 rules JSON Schema expects, so that keys like on and values
+// like no stay strings.
+func schemaDocuments(contents string) ([]interface{}, error) {
+	if json.Valid([]byte(contents)) {
+		document, err := jsonschema.UnmarshalJSON(strings.NewReader(contents))
+		if err != nil {
+			return nil, err
+		}
+		return []interface{}{document}, nil
+	}
+	decoder := yamlv3.NewDecoder(strings.NewReader(contents))
+	var documents []interface{}
+	for {
+		var document interface{}
+		err := decoder.Decode(&document)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if document != nil {
+			documents = append(documents, jsonValue(document))
+		}
+	}
+	if len(documents) == 0 {
+		documents = append(documents, nil)
+	}
+	return documents, nil
+}
+
+// jsonValue gives decoded YAML mappings string keys, as in JSON. YAML allows
+// other keys, such as numbers, which yaml.v3 keeps in a map[interface{}]interface{}.
+func jsonValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[interface{}]interface{}:
+		mapping := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			mapping[fmt.Sprint(key)] = jsonValue(item)
+		}
+		return mapping
+	case map[string]interface{}:
+		for key, item := range value {
+			value[key] = jsonValue(item)
+		}
+		return value
+	case []interface{}:
+		for i, item := range value {
+			value[i] = jsonValue(item)
+		}
+		return value
+	}
+	return value
+}
+
+func jsonPointer(path string, token string) string {
+	token = strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+	return path + "/" + token
+}
@@ -0,0 +1,193 @@
+/*
+Copyright (c) 2023 Peach Pie Labs, LLC.
+*/
+
+package playbook
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSchemaValidate(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"deployment.json": `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "type": "object",
+  "required": ["kind", "metadata", "spec"],
+  "properties": {
+    "kind": {"const": "Deployment"},
+    "metadata": {"$ref": "common.yaml#/$defs/metadata"},
+    "spec": {
+      "type": "object",
+      "properties": {
+        "replicas": {"type": "integer", "minimum": 1},
+        "ports": {
+          "type": "array",
+          "items": {"$ref": "#port"},
+          "uniqueItems": true
+        },
+        "strategy": {"enum": ["Recreate", "RollingUpdate"]}
+      },
+      "additionalProperties": false
+    }
+  },
+  "$defs": {
+    "port": {"$anchor": "port", "type": "integer", "exclusiveMinimum": 0, "maximum": 65535}
+  }
+}`,
+		"common.yaml": `$defs:
+  metadata:
+    type: object
+    required: [name]
+    properties:
+      name:
+        type: string
+        pattern: "^[a-z0-9-]+$"
+        maxLength: 63
+`,
+		"composition.json": `{
+  "type": "object",
+  "properties": {"kind": {"type": "string"}},
+  "oneOf": [
+    {"properties": {"kind": {"const": "a"}}, "required": ["a"]},
+    {"properties": {"kind": {"const": "b"}}, "required": ["b"]}
+  ],
+  "if": {"properties": {"kind": {"const": "a"}}},
+  "then": {"properties": {"a": {"type": "boolean"}}},
+  "unevaluatedProperties": false
+}`,
+		"tuple.json": `{
+  "type": "array",
+  "prefixItems": [{"type": "string"}, {"type": "number"}],
+  "contains": {"type": "null"},
+  "maxContains": 1,
+  "unevaluatedItems": false
+}`,
+		"workflow.yaml": `type: object
+required: ["on", jobs]
+properties:
+  "on":
+    enum: [push, pull_request]
+  jobs:
+    type: object
+  env:
+    additionalProperties:
+      type: string
+`,
+	}
+	for name, contents := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		schema   string
+		contents string
+		want     []SchemaViolation
+	}{
+		{
+			name:     "valid_yaml",
+			schema:   "deployment.json",
+			contents: "kind: Deployment\nmetadata:\n  name: www\nspec:\n  replicas: 2\n  ports: [80, 443]\n  strategy: Recreate\n",
+		},
+		{
+			name:     "valid_json",
+			schema:   "deployment.json",
+			contents: `{"kind": "Deployment", "metadata": {"name": "www"}, "spec": {}}`,
+		},
+		{
+			name:     "violations",
+			schema:   "deployment.json",
+			contents: "kind: Service\nmetadata:\n  name: WWW\nspec:\n  replicas: 0\n  ports: [80, 80, 70000]\n  strategy: Canary\n  paused: true\n",
+			want: []SchemaViolation{
+				{Path: "/kind", Message: "value must be 'Deployment'"},
+				{Path: "/metadata/name", Message: "'WWW' does not match pattern '^[a-z0-9-]+$'"},
+				{Path: "/spec", Message: "additional properties 'paused' not allowed"},
+				{Path: "/spec/ports", Message: "items at 0 and 1 are equal"},
+				{Path: "/spec/ports/2", Message: "maximum: got 70,000, want 65,535"},
+				{Path: "/spec/replicas", Message: "minimum: got 0, want 1"},
+				{Path: "/spec/strategy", Message: "value must be one of 'Recreate', 'RollingUpdate'"},
+			},
+		},
+		{
+			name:     "multiple_documents",
+			schema:   "deployment.json",
+			contents: "kind: Deployment\nmetadata:\n  name: www\nspec: {}\n---\nkind: Deployment\nspec:\n  replicas: two\n",
+			want: []SchemaViolation{
+				{Document: 2, Path: "", Message: "missing property 'metadata'"},
+				{Document: 2, Path: "/spec/replicas", Message: "got string, want integer"},
+			},
+		},
+		{
+			name:     "composition",
+			schema:   "composition.json",
+			contents: `{"kind": "a", "a": true}`,
+		},
+		{
+			name:     "composition_violations",
+			schema:   "composition.json",
+			contents: `{"kind": "a", "a": "yes", "b": 1, "extra": 1}`,
+			want: []SchemaViolation{
+				{Path: "/a", Message: "got string, want boolean"},
+				{Path: "/a", Message: "false schema"},
+				{Path: "/b", Message: "false schema"},
+				{Path: "/extra", Message: "false schema"},
+			},
+		},
+		{
+			name:     "yaml_1_2",
+			schema:   "workflow.yaml",
+			contents: "on: push\njobs: {}\nenv:\n  DEBUG: no\n",
+		},
+		{
+			name:     "tuple",
+			schema:   "tuple.json",
+			contents: `["a", 1, null]`,
+		},
+		{
+			name:     "tuple_violations",
+			schema:   "tuple.json",
+			contents: `[1, "a", null, null, true]`,
+			want: []SchemaViolation{
+				{Path: "", Message: "max 1 items required to match contains schema, but matched 2 items at 2 3"},
+				{Path: "/0", Message: "got number, want string"},
+				{Path: "/1", Message: "got string, want number"},
+				{Path: "/4", Message: "false schema"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSchema(dir, tt.schema, tt.contents)
+			var schemaErr *SchemaError
+			if err != nil && !errors.As(err, &schemaErr) {
+				t.Fatalf("ValidateSchema() error = %v", err)
+			}
+			var got []SchemaViolation
+			if schemaErr != nil {
+				got = schemaErr.Violations
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ValidateSchema() violations = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadSchemaReferences(t *testing.T) {
+	dir := t.TempDir()
+	schema := filepath.Join(dir, "schema.json")
+	if err := os.WriteFile(schema, []byte(`{"properties": {"a": {"$ref": "#/$defs/missing"}}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadSchema(schema); err == nil {
+		t.Errorf("LoadSchema() expected an error for an unresolved $ref")
+	}
+}
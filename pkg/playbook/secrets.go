@@ -0,0 +1,213 @@
+/*
+Copyright (c) 2023 Peach Pie Labs, LLC.
+*/
+
+package playbook
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+const redactedValue = "******"
+
+// Shorter secrets, such as PINs, are only redacted where they appear as a
+// whole word, so that they do not garble the numbers and words they are part
+// of, e.g. the timestamps of log lines.
+const minSubstringRedactedLength = 4
+
+// secretValues holds the secret answers given during the run, which Redact
+// removes from logs and from the errors sent by CaptureError.
+var secretValues = struct {
+	sync.Mutex
+	values []string
+}{}
+
+// IsSecret reports whether the answer to a question is kept out of the
+// manifest, logs and telemetry. Password questions are always secret.
+func (q Question) IsSecret() bool {
+	return q.Secret || q.InputType == "password"
+}
+
+// SecretNames returns the names of the secret answers of a playbook, along
+// with the variables computed from them.
+func SecretNames(playbook Playbook) map[string]bool {
+	secrets := make(map[string]bool)
+	for _, question := range playbook.Questions {
+		if question.IsSecret() {
+			secrets[question.VariableName] = true
+		}
+	}
+	if len(secrets) == 0 {
+		return secrets
+	}
+	for changed := true; changed; {
+		changed = false
+		for name, text := range playbook.Variables {
+			if secrets[name] {
+				continue
+			}
+			fields, err := templateFields(text)
+			if err != nil {
+				continue
+			}
+			for _, field := range fields {
+				if secrets[field] {
+					secrets[name] = true
+					changed = true
+					break
+				}
+			}
+		}
+	}
+	return secrets
+}
+
+// OmitSecrets returns a copy of input_data without the secret answers.
+func OmitSecrets(input_data map[string]interface{}, secrets map[string]bool) map[string]interface{} {
+	answers := make(map[string]interface{}, len(input_data))
+	for key, value := range input_data {
+		if !secrets[key] {
+			answers[key] = value
+		}
+	}
+	return answers
+}
+
+// UsesSecrets reports whether a template refers to a secret answer. Templates
+// that cannot be parsed are assumed to.
+func UsesSecrets(template_source string, secrets map[string]bool) bool {
+	if len(secrets) == 0 {
+		return false
+	}
+	fields, err := templateFields(template_source)
+	if err != nil {
+		return true
+	}
+	for _, field := range fields {
+		if secrets[field] {
+			return true
+		}
+	}
+	return false
+}
+
+// RegisterSecret records a secret answer so that Redact removes it.
+func RegisterSecret(value string) {
+	if value == "" {
+		return
+	}
+	secretValues.Lock()
+	defer secretValues.Unlock()
+	for _, known := range secretValues.values {
+		if known == value {
+			return
+		}
+	}
+	secretValues.values = append(secretValues.values, value)
+	// Replace longer values first, in case one secret contains another
+	sort.Slice(secretValues.values, func(i, j int) bool {
+		return len(secretValues.values[i]) > len(secretValues.values[j])
+	})
+}
+
+// RegisterSecrets records the secret answers, and the variables computed from
+// them, found in input_data.
+func RegisterSecrets(playbook Playbook, input_data map[string]interface{}) {
+	for name := range SecretNames(playbook) {
+		if value, ok := input_data[name]; ok {
+			RegisterSecret(fmt.Sprint(value))
+		}
+	}
+}
+
+// Redact replaces the registered secret answers in text.
+func Redact(text string) string {
+	secretValues.Lock()
+	defer secretValues.Unlock()
+	for _, value := range secretValues.values {
+		if len(value) >= minSubstringRedactedLength {
+			text = strings.ReplaceAll(text, value, redactedValue)
+		} else {
+			text = redactWord(text, value)
+		}
+	}
+	return text
+}
+
+// redactWord replaces value in text where it is not preceded or followed by a
+// letter or a digit.
+func redactWord(text string, value string) string {
+	var redacted strings.Builder
+	for {
+		i := strings.Index(text, value)
+		if i < 0 {
+			break
+		}
+		before, _ := utf8.DecodeLastRuneInString(text[:i])
+		after, _ := utf8.DecodeRuneInString(text[i+len(value):])
+		redacted.WriteString(text[:i])
+		if isWordRune(before) || isWordRune(after) {
+			redacted.WriteString(value)
+		} else {
+			redacted.WriteString(redactedValue)
+		}
+		text = text[i+len(value):]
+	}
+	redacted.WriteString(text)
+	return redacted.String()
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+type redactingWriter struct {
+	w io.Writer
+}
+
+// RedactingWriter returns a writer that redacts the registered secret answers
+// before writing to w. Every write is redacted on its own, which suits loggers
+// writing a line at a time.
+func RedactingWriter(w io.Writer) io.Writer {
+	return redactingWriter{w: w}
+}
+
+func (r redactingWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(r.w, Redact(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// lineRedactingWriter redacts the registered secret answers from the output of
+// a command, one line at a time, so that a secret split across two writes is
+// still redacted. Flush writes the last line when it has no line break.
+type lineRedactingWriter struct {
+	w       io.Writer
+	pending []byte
+}
+
+func (r *lineRedactingWriter) Write(p []byte) (int, error) {
+	r.pending = append(r.pending, p...)
+	if i := bytes.LastIndexByte(r.pending, '\n'); i >= 0 {
+		if _, err := io.WriteString(r.w, Redact(string(r.pending[:i+1]))); err != nil {
+			return 0, err
+		}
+		r.pending = append([]byte{}, r.pending[i+1:]...)
+	}
+	return len(p), nil
+}
+
+func (r *lineRedactingWriter) Flush() {
+	if len(r.pending) > 0 {
+		io.WriteString(r.w, Redact(string(r.pending)))
+		r.pending = nil
+	}
+}
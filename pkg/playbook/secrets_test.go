@@ -0,0 +1,128 @@
+/*
+Copyright (c) 2023 Peach Pie Labs, LLC.
+*/
+
+package playbook
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSecretNames(t *testing.T) {
+	playbook := Playbook{
+		Questions: []Question{
+			{VariableName: "cluster_name", InputType: "textfield"},
+			{VariableName: "api_token", InputType: "password"},
+			{VariableName: "webhook_url", InputType: "textfield", Secret: true},
+		},
+		Variables: Variables{
+			"auth_header": "Bearer {{ .api_token }}",
+			"auth_line":   "Authorization: {{ .auth_header }}",
+			"sa_name":     "{{ .cluster_name }}-sa",
+		},
+	}
+	want := map[string]bool{"api_token": true, "webhook_url": true, "auth_header": true, "auth_line": true}
+	if got := SecretNames(playbook); !reflect.DeepEqual(got, want) {
+		t.Errorf("SecretNames() = %v, want %v", got, want)
+	}
+}
+
+func TestNewGenerationOmitsSecrets(t *testing.T) {
+	input_data := map[string]interface{}{"cluster_name": "web", "api_token": "s3cr3t-token"}
+	generation := NewGeneration("playbook.yaml", input_data, map[string]bool{"api_token": true, "unused": true})
+	if !reflect.DeepEqual(generation.Answers, map[string]interface{}{"cluster_name": "web"}) {
+		t.Errorf("NewGeneration() answers = %v", generation.Answers)
+	}
+	if !reflect.DeepEqual(generation.Secrets, []string{"api_token"}) {
+		t.Errorf("NewGeneration() secrets = %v, want [api_token]", generation.Secrets)
+	}
+	if _, ok := input_data["api_token"]; !ok {
+		t.Error("NewGeneration() modified input_data")
+	}
+}
+
+func TestUsesSecrets(t *testing.T) {
+	secrets := map[string]bool{"api_token": true}
+	tests := []struct {
+		template string
+		want     bool
+	}{
+		{template: `token = "{{ .api_token }}"`, want: true},
+		{template: `{{ if .api_token }}auth = true{{ end }}`, want: true},
+		{template: `name = "{{ .cluster_name }}"`, want: false},
+		{template: `{{ if }}`, want: true},
+	}
+	for _, tt := range tests {
+		if got := UsesSecrets(tt.template, secrets); got != tt.want {
+			t.Errorf("UsesSecrets(%q) = %v, want %v", tt.template, got, tt.want)
+		}
+	}
+}
+
+func TestRedact(t *testing.T) {
+	RegisterSecret("hunter2-redact-test")
+	RegisterSecret("hunter2-redact-test-longer")
+	RegisterSecret("")
+
+	got := Redact("token hunter2-redact-test-longer and hunter2-redact-test")
+	if want := "token ****** and ******"; got != want {
+		t.Errorf("Redact() = %q, want %q", got, want)
+	}
+
+	RegisterSecret("381")
+	got = Redact("pin 381, pin=381, port 13815")
+	if want := "pin ******, pin=******, port 13815"; got != want {
+		t.Errorf("Redact() = %q, want %q", got, want)
+	}
+
+	var buf bytes.Buffer
+	n, err := RedactingWriter(&buf).Write([]byte("invalid answer hunter2-redact-test\n"))
+	if err != nil || n != 35 {
+		t.Fatalf("Write() = %v, %v", n, err)
+	}
+	if buf.String() != "invalid answer ******\n" {
+		t.Errorf("RedactingWriter() wrote %q", buf.String())
+	}
+}
+
+func TestCheckGenerationSecretFile(t *testing.T) {
+	playbook_base_dir := t.TempDir()
+	contents := "token = \"s3cr3t\"\n"
+	for name, text := range map[string]string{"ok.tf": contents, "modified.tf": contents + "# edited\n"} {
+		if err := os.WriteFile(filepath.Join(playbook_base_dir, name), []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	generation := NewGeneration("playbook.yaml", map[string]interface{}{"token": "s3cr3t"}, map[string]bool{"token": true})
+	generation.Files = []GeneratedFile{
+		{TemplateFile: "missing.tpl", OutputFile: "ok.tf", Checksum: Checksum(contents), Secret: true},
+		{TemplateFile: "missing.tpl", OutputFile: "modified.tf", Checksum: Checksum(contents), Secret: true},
+		{TemplateFile: "missing.tpl", OutputFile: "missing.tf", Checksum: Checksum(contents), Secret: true},
+	}
+
+	results, err := CheckGeneration(io.Discard, playbook_base_dir, playbook_base_dir, generation)
+	if err != nil {
+		t.Fatalf("CheckGeneration() error = %v", err)
+	}
+	want := []string{CheckStatusOK, CheckStatusModified, CheckStatusMissing}
+	for i, result := range results {
+		if result.Status != want[i] || result.Diff != "" {
+			t.Errorf("CheckGeneration() %v = %v with diff %q, want %v", result.OutputFile, result.Status, result.Diff, want[i])
+		}
+	}
+
+	updates, err := UpdateGeneration(playbook_base_dir, playbook_base_dir, &generation)
+	if err != nil {
+		t.Fatalf("UpdateGeneration() error = %v", err)
+	}
+	for _, update := range updates {
+		if update.Status != UpdateStatusSkipped {
+			t.Errorf("UpdateGeneration() %v = %v, want %v", update.OutputFile, update.Status, UpdateStatusSkipped)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+/*
+Copyright (c) 2023 Peach Pie Labs, LLC.
+*/
+
+package playbook
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+const defaultSelectPageSize = 10
+
+// SelectOption is an entry of the validValues of a select question. Entries
+// are either plain values, or objects with a label and a description shown
+// at the prompt and a value of any YAML type stored as the answer:
+//
+//	validValues:
+//	  - label: Standard, 4 vCPUs
+//	    value: n2-standard-4
+//	    description: 16 GB of memory
+//	    group: General purpose
+//
+// Options of the same group are listed together under the group name.
+type SelectOption struct {
+	Label       string      `yaml:"label,omitempty"`
+	Value       interface{} `yaml:"value"`
+	Description string      `yaml:"description,omitempty"`
+	Group       string      `yaml:"group,omitempty"`
+}
+
+func (o *SelectOption) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var value string
+	if err := unmarshal(&value); err == nil {
+		*o = SelectOption{Value: value}
+		return nil
+	}
+	type plain SelectOption
+	if err := unmarshal((*plain)(o)); err != nil {
+		return err
+	}
+	if o.Value == nil {
+		return errors.New("validValues entries given as objects must have a value")
+	}
+	return nil
+}
+
+// String returns the value as it is matched against answers.
+func (o SelectOption) String() string {
+	return fmt.Sprint(o.Value)
+}
+
+// plain reports whether the option is a plain string value.
+func (o SelectOption) plain() bool {
+	_, ok := o.Value.(string)
+	return ok && o.Label == "" && o.Description == "" && o.Group == ""
+}
+
+func (q *Question) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain Question
+	if err := unmarshal((*plain)(q)); err != nil {
+		return err
+	}
+	// ValidValues holds the values as strings, and Options is only kept when
+	// some entries are objects
+	q.ValidValues = nil
+	plainOptions := true
+	for _, option := range q.Options {
+		q.ValidValues = append(q.ValidValues, option.String())
+		if !option.plain() {
+			plainOptions = false
+		}
+	}
+	if plainOptions {
+		q.Options = nil
+	}
+	return nil
+}
+
+// hasOptions reports whether Options describes the entries of ValidValues.
+func (q Question) hasOptions() bool {
+	return q.Options != nil && len(q.Options) == len(q.ValidValues)
+}
+
+// SelectOptions returns the entries of a select question with their labels
+// set, whether they were given as plain values or as objects.
+func (q Question) SelectOptions() []SelectOption {
+	options := make([]SelectOption, len(q.ValidValues))
+	for i, value := range q.ValidValues {
+		options[i] = SelectOption{Value: value}
+		if q.hasOptions() {
+			options[i] = q.Options[i]
+		}
+		if options[i].Label == "" {
+			options[i].Label = value
+		}
+	}
+	return options
+}
+
+// AnswerValue returns the value stored in input_data for an answer: the value
+// of the matching entry when validValues are given as objects, and the answer
+// itself otherwise.
+func (q Question) AnswerValue(answer string) interface{} {
+	if q.hasOptions() {
+		for i, value := range q.ValidValues {
+			if value == answer {
+				return q.Options[i].Value
+			}
+		}
+	}
+	return answer
+}
+
+// selectItem is an option as listed at the prompt, with Header holding the
+// group name on the first option of each group and padding on the others.
+// It only holds strings, as the prompt compares items, and values of any YAML
+// type such as lists cannot be compared.
+type selectItem struct {
+	Header      string
+	Label       string
+	Description string
+}
+
+// selectItems lists the options of a select question with the options of each
+// group together, in the order the groups first appear. The options are
+// returned in the same order as the items.
+func selectItems(question Question) ([]selectItem, []SelectOption) {
+	var groups []string
+	grouped := make(map[string][]SelectOption)
+	width := 0
+	for _, option := range question.SelectOptions() {
+		if _, ok := grouped[option.Group]; !ok {
+			groups = append(groups, option.Group)
+		}
+		grouped[option.Group] = append(grouped[option.Group], option)
+		if len(option.Group) > width {
+			width = len(option.Group)
+		}
+	}
+	var items []selectItem
+	var options []SelectOption
+	for _, group := range groups {
+		for i, option := range grouped[group] {
+			item := selectItem{Label: option.Label, Description: option.Description}
+			if width > 0 {
+				item.Header = strings.Repeat(" ", width)
+				if i == 0 {
+					item.Header = group + strings.Repeat(" ", width-len(group))
+				}
+			}
+			items = append(items, item)
+			options = append(options, option)
+		}
+	}
+	return items, options
+}
+
+// fuzzyMatch reports whether the letters and digits of term appear in text in
+// order, ignoring case, so that "euw1" matches europe-west1.
+func fuzzyMatch(term string, text string) bool {
+	text = strings.ToLower(text)
+	for _, r := range strings.ToLower(term) {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			continue
+		}
+		i := strings.IndexRune(text, r)
+		if i < 0 {
+			return false
+		}
+		text = text[i+len(string(r)):]
+	}
+	return true
+}
+
+// searchItems matches a search term against the label, value, description and
+// group of an option.
+func searchItems(options []SelectOption) func(input string, index int) bool {
+	return func(input string, index int) bool {
+		option := options[index]
+		for _, text := range []string{option.Label, option.String(), option.Description, option.Group} {
+			if fuzzyMatch(input, text) {
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -0,0 +1,182 @@
+/*
+Copyright (c) 2023 Peach Pie Labs, LLC.
+*/
+
+package playbook
+
+import (
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/manifoldco/promptui/list"
+	"gopkg.in/yaml.v2"
+)
+
+func TestSelectOptions(t *testing.T) {
+	tests := []struct {
+		name            string
+		yaml            string
+		wantValidValues []string
+		wantOptions     []SelectOption
+		answer          string
+		wantValue       interface{}
+		wantErr         bool
+	}{
+		{
+			name:            "plain",
+			yaml:            "validValues: [A, CNAME, 3]",
+			wantValidValues: []string{"A", "CNAME", "3"},
+			answer:          "3",
+			wantValue:       "3",
+		},
+		{
+			name: "objects",
+			yaml: "validValues:\n" +
+				"  - label: Small\n    value: 2\n    description: 2 vCPUs\n" +
+				"  - label: Large\n    value: 8\n" +
+				"  - custom\n",
+			wantValidValues: []string{"2", "8", "custom"},
+			wantOptions: []SelectOption{
+				{Label: "Small", Value: 2, Description: "2 vCPUs"},
+				{Label: "Large", Value: 8},
+				{Value: "custom"},
+			},
+			answer:    "8",
+			wantValue: 8,
+		},
+		{
+			name:            "boolean_values",
+			yaml:            "validValues:\n  - {label: Enabled, value: true}\n  - {label: Disabled, value: false}\n",
+			wantValidValues: []string{"true", "false"},
+			wantOptions:     []SelectOption{{Label: "Enabled", Value: true}, {Label: "Disabled", Value: false}},
+			answer:          "false",
+			wantValue:       false,
+		},
+		{
+			name:    "missing_value",
+			yaml:    "validValues:\n  - label: Small\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var question Question
+			err := yaml.Unmarshal([]byte("variableName: size\ninputType: select\n"+tt.yaml), &question)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("yaml.Unmarshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(question.ValidValues, tt.wantValidValues) {
+				t.Errorf("ValidValues = %#v, want %#v", question.ValidValues, tt.wantValidValues)
+			}
+			if !reflect.DeepEqual(question.Options, tt.wantOptions) {
+				t.Errorf("Options = %#v, want %#v", question.Options, tt.wantOptions)
+			}
+			if got := question.AnswerValue(tt.answer); !reflect.DeepEqual(got, tt.wantValue) {
+				t.Errorf("AnswerValue() = %#v, want %#v", got, tt.wantValue)
+			}
+			for i, option := range question.SelectOptions() {
+				if option.Label == "" || option.String() != question.ValidValues[i] {
+					t.Errorf("SelectOptions()[%d] = %#v", i, option)
+				}
+			}
+		})
+	}
+}
+
+func TestResolveAnswersSelectValue(t *testing.T) {
+	playbook := Playbook{
+		Questions: []Question{{
+			VariableName: "replicas",
+			InputType:    "select",
+			ValidValues:  []string{"1", "3"},
+			Options:      []SelectOption{{Label: "Single", Value: 1}, {Label: "High availability", Value: 3}},
+		}},
+	}
+	got, err := ResolveAnswers(io.Discard, playbook, t.TempDir(), nil, nil, Target{Answers: map[string]interface{}{"replicas": 3}})
+	if err != nil {
+		t.Fatalf("ResolveAnswers() error = %v", err)
+	}
+	if got["replicas"] != 3 {
+		t.Errorf("ResolveAnswers() replicas = %#v, want 3", got["replicas"])
+	}
+}
+
+func TestSelectItems(t *testing.T) {
+	question := Question{
+		ValidValues: []string{"us-east1", "europe-west1", "us-west1", "other"},
+		Options: []SelectOption{
+			{Value: "us-east1", Group: "Americas"},
+			{Value: "europe-west1", Group: "Europe"},
+			{Value: "us-west1", Group: "Americas"},
+			{Value: "other"},
+		},
+	}
+	items, options := selectItems(question)
+	var got []string
+	for _, item := range items {
+		got = append(got, item.Header+"|"+item.Label)
+	}
+	want := []string{"Americas|us-east1", "        |us-west1", "Europe  |europe-west1", "        |other"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("selectItems() = %q, want %q", got, want)
+	}
+
+	search := searchItems(options)
+	var matched []string
+	for i, item := range items {
+		if search("eur", i) {
+			matched = append(matched, item.Label)
+		}
+	}
+	if !reflect.DeepEqual(matched, []string{"europe-west1"}) {
+		t.Errorf("searchItems() matched %v, want [europe-west1]", matched)
+	}
+}
+
+func TestSelectItemsListValue(t *testing.T) {
+	question := Question{
+		ValidValues: []string{"[a b]", "map[zone:b]"},
+		Options: []SelectOption{
+			{Label: "Two zones", Value: []interface{}{"a", "b"}},
+			{Label: "One zone", Value: map[interface{}]interface{}{"zone": "b"}},
+		},
+	}
+	items, options := selectItems(question)
+	// The prompt looks up the selected item by comparing it to the others
+	prompt, err := list.New(items, defaultSelectPageSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prompt.SetCursor(1)
+	index := prompt.Index()
+	if index != 1 || items[index].Label != "One zone" {
+		t.Fatalf("Index() = %v, want 1", index)
+	}
+	if got := question.AnswerValue(options[index].String()); !reflect.DeepEqual(got, question.Options[1].Value) {
+		t.Errorf("AnswerValue() = %#v, want %#v", got, question.Options[1].Value)
+	}
+}
+
+func TestFuzzyMatch(t *testing.T) {
+	tests := []struct {
+		term string
+		text string
+		want bool
+	}{
+		{term: "", text: "us-east1", want: true},
+		{term: "euw1", text: "europe-west1", want: true},
+		{term: "Europe West", text: "europe-west1", want: true},
+		{term: "n2 std 4", text: "n2-standard-4", want: true},
+		{term: "west1eu", text: "europe-west1", want: false},
+		{term: "asia", text: "us-east1", want: false},
+	}
+	for _, tt := range tests {
+		if got := fuzzyMatch(tt.term, tt.text); got != tt.want {
+			t.Errorf("fuzzyMatch(%q, %q) = %v, want %v", tt.term, tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+/*
+Copyright (c) 2023 Peach Pie Labs, LLC.
+*/
+
+package playbook
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+const (
+	SyntaxJSON = "json"
+	SyntaxYAML = "yaml"
+	SyntaxHCL  = "hcl"
+	SyntaxTOML = "toml"
+)
+
+// SyntaxError reports where a rendered output fails to parse. Line and Column
+// are 1-based; Column is 0 when the parser only reports the line.
+type SyntaxError struct {
+	Syntax  string
+	Line    int
+	Column  int
+	Message string
+	Snippet string
+}
+
+func (e *SyntaxError) Error() string {
+	position := strconv.Itoa(e.Line)
+	if e.Column > 0 {
+		position += ":" + strconv.Itoa(e.Column)
+	}
+	return fmt.Sprintf("invalid %s at line %s: %s\n%s", e.Syntax, position, e.Message, e.Snippet)
+}
+
+func validateSyntaxName(syntax string) error {
+	switch syntax {
+	case "", SyntaxJSON, SyntaxYAML, SyntaxHCL, SyntaxTOML:
+		return nil
+	}
+	return fmt.Errorf("invalid validateAs %q. valid values are %s, %s, %s and %s", syntax, SyntaxJSON, SyntaxYAML, SyntaxHCL, SyntaxTOML)
+}
+
+// ValidateSyntax parses rendered contents as the given syntax. It returns a
+// *SyntaxError pointing at the first problem found.
+func ValidateSyntax(contents string, syntax string) error {
+	var err *SyntaxError
+	switch syntax {
+	case "":
+		return nil
+	case SyntaxJSON:
+		err = validateJSON(contents)
+	case SyntaxYAML:
+		err = validateYAML(contents)
+	case SyntaxHCL:
+		err = validateHCL(contents)
+	case SyntaxTOML:
+		err = validateTOML(contents)
+	default:
+		return validateSyntaxName(syntax)
+	}
+	if err == nil {
+		return nil
+	}
+	err.Syntax = syntax
+	err.Snippet = syntaxSnippet(contents, err.Line, err.Column)
+	return err
+}
+
+// syntaxSnippet shows the lines around line, with a caret under column.
+func syntaxSnippet(contents string, line int, column int) string {
+	lines := strings.Split(contents, "\n")
+	var snippet strings.Builder
+	for i := line - 2; i <= line; i++ {
+		if i < 1 || i > len(lines) {
+			continue
+		}
+		snippet.WriteString(fmt.Sprintf("%5d | %s\n", i, strings.TrimRight(lines[i-1], "\r")))
+	}
+	if column > 0 {
+		snippet.WriteString(fmt.Sprintf("%5s | %s^\n", "", strings.Repeat(" ", column-1)))
+	}
+	return snippet.String()
+}
+
+// position converts a byte offset into a 1-based line and column.
+func position(contents string, offset int) (int, int) {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > len(contents) {
+		offset = len(contents)
+	}
+	before := contents[:offset]
+	line := strings.Count(before, "\n") + 1
+	column := len([]rune(before[strings.LastIndex(before, "\n")+1:])) + 1
+	return line, column
+}
+
+func validateJSON(contents string) *SyntaxError {
+	decoder := json.NewDecoder(strings.NewReader(contents))
+	var value interface{}
+	err := decoder.Decode(&value)
+	if err == nil {
+		// Only a single JSON value is allowed
+		offset := int(decoder.InputOffset())
+		if _, err = decoder.Token(); err == io.EOF {
+			return nil
+		}
+		offset += len(contents[offset:]) - len(strings.TrimLeft(contents[offset:], " \t\r\n"))
+		line, column := position(contents, offset)
+		return &SyntaxError{Line: line, Column: column, Message: "unexpected content after the JSON value"}
+	}
+	if syntaxErr, ok := err.(*json.SyntaxError); ok {
+		// Offset counts the bytes read, including the offending one
+		line, column := position(contents, int(syntaxErr.Offset)-1)
+		return &SyntaxError{Line: line, Column: column, Message: syntaxErr.Error()}
+	}
+	line, column := position(contents, len(contents))
+	return &SyntaxError{Line: line, Column: column, Message: err.Error()}
+}
+
+var yamlErrorLinePattern = regexp.MustCompile(`line (\d+): `)
+
+// validateYAML parses contents with the YAML 1.2 rules, as schema validation
+// does, so keys such as on and no are strings and may not be repeated.
+func validateYAML(contents string) *SyntaxError {
+	decoder := yamlv3.NewDecoder(strings.NewReader(contents))
+	for {
+		var value interface{}
+		err := decoder.Decode(&value)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			message := strings.TrimPrefix(err.Error(), "yaml: ")
+			message = strings.TrimPrefix(message, "unmarshal errors:\n  ")
+			line := strings.Count(contents, "\n") + 1
+			if matches := yamlErrorLinePattern.FindStringSubmatch(message); matches != nil {
+				line, _ = strconv.Atoi(matches[1])
+				message = strings.Replace(message, matches[0], "", 1)
+			}
+			return &SyntaxError{Line: line, Message: message}
+		}
+	}
+}
@@ -0,0 +1,31 @@
+/*
+Copyright (c) 2023 Peach Pie Labs, LLC.
+*/
+
+package playbook
+
+import (
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// validateHCL parses HCL native syntax, as written for Terraform and Packer,
+// and reports the first error. Attributes and blocks are not checked against
+// any schema.
+func validateHCL(contents string) *SyntaxError {
+	_, diagnostics := hclsyntax.ParseConfig([]byte(contents), "", hcl.InitialPos)
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Severity != hcl.DiagError {
+			continue
+		}
+		message := diagnostic.Summary
+		if diagnostic.Detail != "" {
+			message += ". " + diagnostic.Detail
+		}
+		if diagnostic.Subject == nil {
+			return &SyntaxError{Line: 1, Message: message}
+		}
+		return &SyntaxError{Line: diagnostic.Subject.Start.Line, Column: diagnostic.Subject.Start.Column, Message: message}
+	}
+	return nil
+}
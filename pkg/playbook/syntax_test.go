@@ -0,0 +1,194 @@
+/*
+Copyright (c) 2023 Peach Pie Labs, LLC.
+*/
+
+package playbook
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateSyntax(t *testing.T) {
+	tests := []struct {
+		name       string
+		contents   string
+		syntax     string
+		wantErr    bool
+		wantLine   int
+		wantColumn int
+	}{
+		{
+			name:     "none",
+			contents: "{{",
+			syntax:   "",
+		},
+		{
+			name:     "json",
+			contents: "{\n  \"name\": \"www\",\n  \"records\": [1, 2]\n}\n",
+			syntax:   SyntaxJSON,
+		},
+		{
+			name:       "json_trailing_comma",
+			contents:   "{\n  \"name\": \"www\",\n}\n",
+			syntax:     SyntaxJSON,
+			wantErr:    true,
+			wantLine:   3,
+			wantColumn: 1,
+		},
+		{
+			name:       "json_two_values",
+			contents:   "{}\n{}\n",
+			syntax:     SyntaxJSON,
+			wantErr:    true,
+			wantLine:   2,
+			wantColumn: 1,
+		},
+		{
+			name:     "yaml",
+			contents: "name: www\nspec:\n  ttl: 300\n---\n- a\n",
+			syntax:   SyntaxYAML,
+		},
+		{
+			name:     "yaml_bad_indentation",
+			contents: "name: www\nspec:\n  ttl: 300\n zone: example.com\n",
+			syntax:   SyntaxYAML,
+			wantErr:  true,
+			wantLine: 3,
+		},
+		{
+			name:     "yaml_1_2_keys",
+			contents: "on: push\ntrue: x\nno: 1\nn: 2\n",
+			syntax:   SyntaxYAML,
+		},
+		{
+			name:     "yaml_duplicate_key",
+			contents: "name: www\nttl: 300\nname: api\n",
+			syntax:   SyntaxYAML,
+			wantErr:  true,
+			wantLine: 3,
+		},
+		{
+			name: "hcl",
+			contents: "resource \"aws_s3_bucket\" \"b\" {\n" +
+				"  bucket = \"${var.name}-logs\" # comment\n" +
+				"  tags   = { Name = \"logs\" }\n" +
+				"  policy = <<-EOF\n    { \"Version\": \"2012-10-17\"\n  EOF\n" +
+				"  /* list */\n  ids = [for s in var.ids : upper(s)]\n}\n",
+			syntax: SyntaxHCL,
+		},
+		{
+			name:       "hcl_unclosed_block",
+			contents:   "module \"a\" {\n  source = \"./a\"\n",
+			syntax:     SyntaxHCL,
+			wantErr:    true,
+			wantLine:   1,
+			wantColumn: 12,
+		},
+		{
+			name:       "hcl_mismatched_bracket",
+			contents:   "locals {\n  ids = [1, 2}\n}\n",
+			syntax:     SyntaxHCL,
+			wantErr:    true,
+			wantLine:   2,
+			wantColumn: 14,
+		},
+		{
+			name:       "hcl_unterminated_string",
+			contents:   "name = \"www\nzone = \"example.com\"\n",
+			syntax:     SyntaxHCL,
+			wantErr:    true,
+			wantLine:   1,
+			wantColumn: 12,
+		},
+		{
+			name: "toml",
+			contents: "# service\ntitle = \"www\"\nport = 8_080\nratio = 0.5\nenabled = true\n" +
+				"created = 1979-05-27 07:32:00Z\n\n[owner]\nname.first = 'Tom' # inline\n" +
+				"tags = [\n  \"a\", # first\n  \"b\",\n]\npoint = { x = 1, y = -2 }\n" +
+				"description = \"\"\"\nline one\nline two\"\"\"\n\n[[servers]]\nhost = \"10.0.0.1\"\n",
+			syntax: SyntaxTOML,
+		},
+		{
+			name:       "toml_unquoted_string",
+			contents:   "[owner]\nname = Tom\n",
+			syntax:     SyntaxTOML,
+			wantErr:    true,
+			wantLine:   2,
+			wantColumn: 8,
+		},
+		{
+			name:       "toml_unclosed_table",
+			contents:   "[owner\nname = \"Tom\"\n",
+			syntax:     SyntaxTOML,
+			wantErr:    true,
+			wantLine:   1,
+			wantColumn: 7,
+		},
+		{
+			name:       "toml_unclosed_array",
+			contents:   "ports = [\n  80,\n  443\n",
+			syntax:     SyntaxTOML,
+			wantErr:    true,
+			wantLine:   3,
+			wantColumn: 6,
+		},
+		{
+			name:       "toml_content_after_value",
+			contents:   "name = \"www\" \"extra\"\n",
+			syntax:     SyntaxTOML,
+			wantErr:    true,
+			wantLine:   1,
+			wantColumn: 13,
+		},
+		{
+			name:       "hcl_missing_expression",
+			contents:   "resource \"a\" \"b\" {\n  name = \n}\n",
+			syntax:     SyntaxHCL,
+			wantErr:    true,
+			wantLine:   2,
+			wantColumn: 10,
+		},
+		{
+			name:       "hcl_missing_separator",
+			contents:   "a = {\n  b = 1 c = 2\n}\n",
+			syntax:     SyntaxHCL,
+			wantErr:    true,
+			wantLine:   2,
+			wantColumn: 9,
+		},
+		{
+			name:       "toml_duplicate_key",
+			contents:   "a = 1\na = 2\n",
+			syntax:     SyntaxTOML,
+			wantErr:    true,
+			wantLine:   2,
+			wantColumn: 1,
+		},
+		{
+			name:     "unknown_syntax",
+			contents: "a",
+			syntax:   "xml",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSyntax(tt.contents, tt.syntax)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateSyntax() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				return
+			}
+			if syntaxErr.Line != tt.wantLine || syntaxErr.Column != tt.wantColumn {
+				t.Errorf("ValidateSyntax() position = %d:%d, want %d:%d (%v)", syntaxErr.Line, syntaxErr.Column, tt.wantLine, tt.wantColumn, err)
+			}
+			if !strings.Contains(syntaxErr.Snippet, "^") && syntaxErr.Column > 0 {
+				t.Errorf("ValidateSyntax() snippet %q has no caret", syntaxErr.Snippet)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+/*
+Copyright (c) 2023 Peach Pie Labs, LLC.
+*/
+
+package playbook
+
+import (
+	"errors"
+
+	"github.com/BurntSushi/toml"
+)
+
+// validateTOML decodes TOML and reports the first error, including keys and
+// tables defined more than once.
+func validateTOML(contents string) *SyntaxError {
+	var value map[string]interface{}
+	_, err := toml.Decode(contents, &value)
+	if err == nil {
+		return nil
+	}
+	var parseErr toml.ParseError
+	if !errors.As(err, &parseErr) {
+		return &SyntaxError{Line: 1, Message: err.Error()}
+	}
+	line, column := position(contents, parseErr.Position.Start)
+	return &SyntaxError{Line: line, Column: column, Message: parseErr.Message}
+}
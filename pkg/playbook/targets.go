@@ -0,0 +1,145 @@
+/*
+Copyright (c) 2023 Peach Pie Labs, LLC.
+*/
+
+package playbook
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Targets lists the repositories a playbook is applied to with
+// `gitformer run --targets`, along with the answers shared by all of them.
+type Targets struct {
+	Concurrency int                    `yaml:"concurrency,omitempty"`
+	Answers     map[string]interface{} `yaml:"answers,omitempty"`
+	Targets     []Target               `yaml:"targets"`
+}
+
+type Target struct {
+	// Repo is the path or URL of the repository
+	Repo string `yaml:"repo"`
+	// Answers override the shared answers for this repository
+	Answers map[string]interface{} `yaml:"answers,omitempty"`
+	// ForgeRepo is the repository on the forge, when it cannot be derived from Repo
+	ForgeRepo string `yaml:"forgeRepo,omitempty"`
+}
+
+func LoadTargetsFile(file_path string) (Targets, error) {
+	byteValue, err := os.ReadFile(file_path)
+	if err != nil {
+		return Targets{}, err
+	}
+	var targets Targets
+	err = yaml.Unmarshal(byteValue, &targets)
+	if err != nil {
+		return Targets{}, err
+	}
+
+	if len(targets.Targets) == 0 {
+		return Targets{}, errors.New("no targets provided. the targets file must list at least one repository")
+	}
+	for i, target := range targets.Targets {
+		if target.Repo == "" {
+			return Targets{}, fmt.Errorf("no repo given for target %d. every target must have a repo", i+1)
+		}
+	}
+	if targets.Concurrency < 0 {
+		return Targets{}, errors.New("concurrency must be a positive number")
+	}
+	return targets, nil
+}
+
+// ResolveAnswers combines the answers for a target, in increasing order of
+// precedence: prompted answers, shared answers and the target's own answers.
+// Every question must be answered, and every answer is transformed and
+// validated like an answer entered at the prompt, with the options of select
+// questions read from output_root, and select answers stored as the value of
+// their option. The playbook variables are computed as the answers they refer
+// to are resolved. Warnings are printed to out.
+func ResolveAnswers(out io.Writer, playbook Playbook, output_root string, prompted map[string]interface{}, shared map[string]interface{}, target Target) (map[string]interface{}, error) {
+	input_data := make(map[string]interface{})
+	for _, answers := range []map[string]interface{}{prompted, shared, target.Answers} {
+		for key, value := range answers {
+			input_data[key] = value
+		}
+	}
+
+	answers := make(map[string]interface{})
+	for _, question := range playbook.Questions {
+		value, ok := input_data[question.VariableName]
+		if !ok {
+			return nil, fmt.Errorf("no answer provided for %s", question.VariableName)
+		}
+		answers[question.VariableName] = value
+		delete(input_data, question.VariableName)
+	}
+	evaluateVariables := func() error {
+		if err := EvaluateVariables(playbook, input_data); err != nil {
+			return err
+		}
+		RegisterSecrets(playbook, input_data)
+		return nil
+	}
+	if err := evaluateVariables(); err != nil {
+		return nil, err
+	}
+
+	for _, question := range playbook.Questions {
+		question, err := RenderQuestion(question, input_data)
+		if err != nil {
+			return nil, err
+		}
+		question, err = ResolveOptions(out, question, output_root, input_data)
+		if err != nil {
+			return nil, err
+		}
+		input_data[question.VariableName], err = resolveAnswer(question, answers[question.VariableName])
+		if err != nil {
+			return nil, fmt.Errorf("invalid answer for %s: %w", question.VariableName, err)
+		}
+		if err := evaluateVariables(); err != nil {
+			return nil, err
+		}
+	}
+	return input_data, nil
+}
+
+// resolveAnswer transforms and validates an answer from a targets file. The
+// items of a list are resolved one by one and kept in a list, e.g. for
+// forEach, and maps are kept as they are.
+func resolveAnswer(question Question, value interface{}) (interface{}, error) {
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.Map:
+		return value, nil
+	case reflect.Slice, reflect.Array:
+		list := reflect.ValueOf(value)
+		items := make([]interface{}, list.Len())
+		for i := range items {
+			item, err := resolveAnswer(question, list.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return items, nil
+	}
+	answer := fmt.Sprint(value)
+	if question.IsSecret() {
+		RegisterSecret(answer)
+	}
+	answer, err := TransformAnswer(question, answer)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateAnswer(answer, question); err != nil {
+		return nil, err
+	}
+	return question.AnswerValue(answer), nil
+}
@@ -0,0 +1,105 @@
+/*
+Copyright (c) 2023 Peach Pie Labs, LLC.
+*/
+
+package playbook
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadTargetsFile(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "targets.yaml")
+	err := os.WriteFile(valid, []byte("answers:\n  ttl: 300\ntargets:\n  - repo: ../dns\n    answers:\n      subdomain_name: www.example.com\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	missingRepo := filepath.Join(dir, "missing_repo.yaml")
+	err = os.WriteFile(missingRepo, []byte("targets:\n  - answers:\n      ttl: 300\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	targets, err := LoadTargetsFile(valid)
+	if err != nil {
+		t.Fatalf("LoadTargetsFile() error = %v", err)
+	}
+	if len(targets.Targets) != 1 || targets.Targets[0].Repo != "../dns" || targets.Answers["ttl"] != 300 {
+		t.Errorf("LoadTargetsFile() = %v", targets)
+	}
+	if _, err = LoadTargetsFile(missingRepo); err == nil {
+		t.Errorf("LoadTargetsFile() wanted error for a target without repo")
+	}
+}
+
+func TestResolveAnswers(t *testing.T) {
+	playbook := Playbook{Questions: zone_record_playbook_data.Questions[:4]}
+	shared := map[string]interface{}{"record_type": "A", "record_value": "10.0.0.1", "ttl": 300}
+
+	got, err := ResolveAnswers(io.Discard, playbook, t.TempDir(), nil, shared, Target{Answers: map[string]interface{}{"subdomain_name": "www.example.com", "ttl": 60}})
+	if err != nil {
+		t.Fatalf("ResolveAnswers() error = %v", err)
+	}
+	if got["ttl"] != "60" || got["subdomain_name"] != "www.example.com" {
+		t.Errorf("ResolveAnswers() = %v", got)
+	}
+
+	if _, err = ResolveAnswers(io.Discard, playbook, t.TempDir(), nil, shared, Target{}); err == nil {
+		t.Errorf("ResolveAnswers() wanted error for a missing answer")
+	}
+	if _, err = ResolveAnswers(io.Discard, playbook, t.TempDir(), nil, shared, Target{Answers: map[string]interface{}{"subdomain_name": "www", "record_type": "MX"}}); err == nil {
+		t.Errorf("ResolveAnswers() wanted error for invalid answers")
+	}
+}
+
+func TestResolveAnswersTransform(t *testing.T) {
+	playbook := Playbook{
+		Questions: []Question{{
+			VariableName: "cluster_name",
+			InputType:    "textfield",
+			Transform:    []Transform{{Name: TransformTrim}, {Name: TransformKebab}},
+		}},
+		Variables: Variables{"sa_name": "{{ .cluster_name }}-sa"},
+	}
+	got, err := ResolveAnswers(io.Discard, playbook, t.TempDir(), nil, nil, Target{Answers: map[string]interface{}{"cluster_name": " Web Frontend "}})
+	if err != nil {
+		t.Fatalf("ResolveAnswers() error = %v", err)
+	}
+	if got["cluster_name"] != "web-frontend" || got["sa_name"] != "web-frontend-sa" {
+		t.Errorf("ResolveAnswers() = %v", got)
+	}
+}
+
+func TestResolveAnswersForEach(t *testing.T) {
+	playbook := Playbook{
+		Questions: []Question{{
+			VariableName: "environments",
+			InputType:    "textfield",
+			Transform:    []Transform{{Name: TransformLower}},
+			Validation:   "hostname",
+		}},
+		Outputs: []Output{{TemplateFile: "environment.tpl", OutputFile: "{{.item}}.tfvars", ForEach: "environments"}},
+	}
+	target := Target{Answers: map[string]interface{}{"environments": []interface{}{"Dev", "prod"}}}
+	got, err := ResolveAnswers(io.Discard, playbook, t.TempDir(), nil, nil, target)
+	if err != nil {
+		t.Fatalf("ResolveAnswers() error = %v", err)
+	}
+	items, err := ForEachItems(got, "environments")
+	if err != nil {
+		t.Fatalf("ForEachItems() error = %v", err)
+	}
+	if want := []interface{}{"dev", "prod"}; !reflect.DeepEqual(items, want) {
+		t.Errorf("ForEachItems() = %v, want %v", items, want)
+	}
+
+	target = Target{Answers: map[string]interface{}{"environments": []interface{}{"dev", "not valid"}}}
+	if _, err = ResolveAnswers(io.Discard, playbook, t.TempDir(), nil, nil, target); err == nil {
+		t.Errorf("ResolveAnswers() wanted error for an invalid item")
+	}
+}
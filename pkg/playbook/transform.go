@@ -0,0 +1,152 @@
+/*
+Copyright (c) 2023 Peach Pie Labs, LLC.
+*/
+
+package playbook
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+const (
+	TransformTrim    = "trim"
+	TransformLower   = "lower"
+	TransformUpper   = "upper"
+	TransformSlug    = "slug"
+	TransformSnake   = "snake"
+	TransformKebab   = "kebab"
+	TransformCamel   = "camel"
+	TransformReplace = "replace"
+)
+
+var transformNames = []string{TransformTrim, TransformLower, TransformUpper, TransformSlug, TransformSnake, TransformKebab, TransformCamel}
+
+// Transform changes an answer before it is validated and stored. Transforms
+// are given by name, or as a regular expression replacement:
+//
+//	transform:
+//	  - trim
+//	  - replace: "[^a-z0-9-]+"
+//	    with: ""
+type Transform struct {
+	Name string `yaml:"-"`
+	// Replace is the regular expression replaced by With, which may refer
+	// to the submatches as $1, $2...
+	Replace string `yaml:"replace,omitempty"`
+	With    string `yaml:"with,omitempty"`
+}
+
+func (t *Transform) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var name string
+	if err := unmarshal(&name); err == nil {
+		*t = Transform{Name: name}
+		return nil
+	}
+	type plain Transform
+	if err := unmarshal((*plain)(t)); err != nil {
+		return err
+	}
+	t.Name = TransformReplace
+	return nil
+}
+
+func validateTransforms(question Question) error {
+	// The answers of select questions must stay one of their options
+	if question.InputType == "select" && len(question.Transform) > 0 {
+		return fmt.Errorf("transform of question %s is not allowed with inputType select", question.VariableName)
+	}
+	for _, transform := range question.Transform {
+		if transform.Name == TransformReplace {
+			if transform.Replace == "" {
+				return fmt.Errorf("no regular expression given in a replace transform of question %s", question.VariableName)
+			}
+			if _, err := regexp.Compile(transform.Replace); err != nil {
+				return fmt.Errorf("invalid replace transform %q of question %s: %w", transform.Replace, question.VariableName, err)
+			}
+			continue
+		}
+		valid := false
+		for _, name := range transformNames {
+			if transform.Name == name {
+				valid = true
+			}
+		}
+		if !valid {
+			return fmt.Errorf("invalid transform %q for question %s. valid transforms are %s and replace", transform.Name, question.VariableName, strings.Join(transformNames, ", "))
+		}
+	}
+	return nil
+}
+
+// TransformAnswer applies the transforms of a question to an answer in order.
+func TransformAnswer(question Question, answer string) (string, error) {
+	for _, transform := range question.Transform {
+		switch transform.Name {
+		case TransformTrim:
+			answer = strings.TrimSpace(answer)
+		case TransformLower:
+			answer = strings.ToLower(answer)
+		case TransformUpper:
+			answer = strings.ToUpper(answer)
+		case TransformSlug:
+			answer = strings.Trim(slugPattern.ReplaceAllString(strings.ToLower(answer), "-"), "-")
+		case TransformSnake:
+			answer = strings.ToLower(strings.Join(splitWords(answer), "_"))
+		case TransformKebab:
+			answer = strings.ToLower(strings.Join(splitWords(answer), "-"))
+		case TransformCamel:
+			words := splitWords(answer)
+			for i, word := range words {
+				letters := []rune(strings.ToLower(word))
+				if i > 0 {
+					letters[0] = unicode.ToUpper(letters[0])
+				}
+				words[i] = string(letters)
+			}
+			answer = strings.Join(words, "")
+		case TransformReplace:
+			pattern, err := regexp.Compile(transform.Replace)
+			if err != nil {
+				return "", fmt.Errorf("invalid replace transform %q of question %s: %w", transform.Replace, question.VariableName, err)
+			}
+			answer = pattern.ReplaceAllString(answer, transform.With)
+		default:
+			return "", fmt.Errorf("invalid transform %q for question %s", transform.Name, question.VariableName)
+		}
+	}
+	return answer, nil
+}
+
+var slugPattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+// splitWords splits text into words at every character that is not a letter
+// or a digit, and where a lower case letter or digit is followed by an upper
+// case one, so that "MyCluster name" gives My, Cluster and name.
+func splitWords(text string) []string {
+	var words []string
+	var word []rune
+	var previous rune
+	for _, r := range text {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if len(word) > 0 {
+				words = append(words, string(word))
+				word = nil
+			}
+			previous = r
+			continue
+		}
+		if unicode.IsUpper(r) && len(word) > 0 && (unicode.IsLower(previous) || unicode.IsDigit(previous)) {
+			words = append(words, string(word))
+			word = nil
+		}
+		word = append(word, r)
+		previous = r
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+	return words
+}
@@ -0,0 +1,76 @@
+/*
+Copyright (c) 2023 Peach Pie Labs, LLC.
+*/
+
+package playbook
+
+import (
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestTransformAnswer(t *testing.T) {
+	tests := []struct {
+		name       string
+		transforms []Transform
+		answer     string
+		want       string
+	}{
+		{name: "trim", transforms: []Transform{{Name: TransformTrim}}, answer: "  web \n", want: "web"},
+		{name: "lower", transforms: []Transform{{Name: TransformLower}}, answer: "Web", want: "web"},
+		{name: "upper", transforms: []Transform{{Name: TransformUpper}}, answer: "us-east1", want: "US-EAST1"},
+		{name: "slug", transforms: []Transform{{Name: TransformSlug}}, answer: " My Cluster (prod)! ", want: "my-cluster-prod"},
+		{name: "snake", transforms: []Transform{{Name: TransformSnake}}, answer: "MyCluster name-2", want: "my_cluster_name_2"},
+		{name: "kebab", transforms: []Transform{{Name: TransformKebab}}, answer: "apiGateway v2", want: "api-gateway-v2"},
+		{name: "camel", transforms: []Transform{{Name: TransformCamel}}, answer: "cluster_name-ID", want: "clusterNameId"},
+		{name: "replace", transforms: []Transform{{Name: TransformReplace, Replace: `^(\w+)\.example\.com$`, With: "$1"}}, answer: "api.example.com", want: "api"},
+		{name: "in_order", transforms: []Transform{{Name: TransformTrim}, {Name: TransformReplace, Replace: `\s+`, With: "_"}, {Name: TransformUpper}}, answer: " a b  c ", want: "A_B_C"},
+		{name: "none", answer: " Web ", want: " Web "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := TransformAnswer(Question{VariableName: "name", Transform: tt.transforms}, tt.answer)
+			if err != nil {
+				t.Fatalf("TransformAnswer() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("TransformAnswer() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateTransforms(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		want    []Transform
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			yaml: "transform:\n  - trim\n  - replace: '[^a-z]+'\n    with: '-'\n",
+			want: []Transform{{Name: TransformTrim}, {Name: TransformReplace, Replace: "[^a-z]+", With: "-"}},
+		},
+		{name: "unknown", yaml: "transform: [title]\n", want: []Transform{{Name: "title"}}, wantErr: true},
+		{name: "missing_pattern", yaml: "transform:\n  - with: '-'\n", want: []Transform{{Name: TransformReplace, With: "-"}}, wantErr: true},
+		{name: "invalid_pattern", yaml: "transform:\n  - replace: '('\n", want: []Transform{{Name: TransformReplace, Replace: "("}}, wantErr: true},
+		{name: "select", yaml: "inputType: select\ntransform: [lower]\n", want: []Transform{{Name: TransformLower}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var question Question
+			if err := yaml.Unmarshal([]byte("variableName: name\n"+tt.yaml), &question); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(question.Transform, tt.want) {
+				t.Errorf("Transform = %#v, want %#v", question.Transform, tt.want)
+			}
+			if err := validateTransforms(question); (err != nil) != tt.wantErr {
+				t.Errorf("validateTransforms() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+/*
+Copyright (c) 2023 Peach Pie Labs, LLC.
+*/
+
+package playbook
+
+import (
+	"os"
+	"path/filepath"
+)
+
+const (
+	UpdateStatusUnchanged = "unchanged"
+	UpdateStatusUpdated   = "updated"
+	UpdateStatusMerged    = "merged"
+	UpdateStatusConflict  = "conflict"
+	UpdateStatusSkipped   = "skipped"
+)
+
+type UpdateResult struct {
+	OutputFile string
+	Status     string
+	Reason     string
+	Conflicts  []MergeConflict
+}
+
+// UpdateGeneration re-renders every file of a generation with the current
+// templates from playbook_base_dir and merges the result into the file on disk
+// under output_root, the directory of the manifest. The output of the
+// template version recorded in the manifest is used as the merge base, so
+// edits made by hand since the generation are kept. For files changed by
+// hooks, the recorded output is the merge base instead, and the file is left
+// as it is when its template renders the same. Hooks are not run, so a file
+// whose template changed is written as rendered. The generation is updated in
+// place to point at the new template versions.
+func UpdateGeneration(playbook_base_dir string, output_root string, generation *Generation) ([]UpdateResult, error) {
+	var results []UpdateResult
+	for i := range generation.Files {
+		file := &generation.Files[i]
+		result := UpdateResult{OutputFile: file.OutputFile}
+
+		if file.Mode == OutputModeAppend {
+			result.Status = UpdateStatusSkipped
+			result.Reason = "appended outputs cannot be updated"
+			results = append(results, result)
+			continue
+		}
+		if file.Secret {
+			result.Status = UpdateStatusSkipped
+			result.Reason = "rendered with secret answers, which are not recorded in the manifest"
+			results = append(results, result)
+			continue
+		}
+		template_source, err := ReadTemplateSource(playbook_base_dir, file.TemplateFile)
+		if err != nil {
+			result.Status = UpdateStatusSkipped
+			result.Reason = "template file " + file.TemplateFile + " is no longer available"
+			results = append(results, result)
+			continue
+		}
+		outputFilePath := filepath.Join(output_root, file.OutputFile)
+		currentContents, err := os.ReadFile(outputFilePath)
+		if os.IsNotExist(err) {
+			result.Status = UpdateStatusSkipped
+			result.Reason = "output file no longer exists"
+			results = append(results, result)
+			continue
+		}
+		if err != nil {
+			return results, err
+		}
+
+		baseContents, err := renderGeneratedFile(*generation, *file, file.Template)
+		if err != nil {
+			return results, err
+		}
+		renderedFileContents, err := renderGeneratedFile(*generation, *file, template_source)
+		if err != nil {
+			return results, err
+		}
+		if file.Output != "" {
+			if renderedFileContents == baseContents {
+				renderedFileContents = file.Output
+			}
+			baseContents = file.Output
+		}
+
+		merge := ThreeWayMerge(baseContents, string(currentContents), renderedFileContents)
+		if merge.Content == string(currentContents) {
+			result.Status = UpdateStatusUnchanged
+		} else {
+			err = overwriteToFile(outputFilePath, merge.Content)
+			if err != nil {
+				return results, err
+			}
+			if len(merge.Conflicts) > 0 {
+				result.Status = UpdateStatusConflict
+				result.Conflicts = merge.Conflicts
+			} else if string(currentContents) == baseContents {
+				result.Status = UpdateStatusUpdated
+			} else {
+				result.Status = UpdateStatusMerged
+			}
+		}
+
+		if renderedFileContents != file.Output {
+			file.Output = ""
+		}
+		file.Template = template_source
+		file.Checksum = Checksum(renderedFileContents)
+		results = append(results, result)
+	}
+	return results, nil
+}
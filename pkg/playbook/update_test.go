@@ -0,0 +1,104 @@
+/*
+Copyright (c) 2023 Peach Pie Labs, LLC.
+*/
+
+package playbook
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUpdateGeneration(t *testing.T) {
+	playbook_base_dir := t.TempDir()
+	old_template := "name = \"{{.name}}\"\nsize = 1\n"
+	new_template := "name = \"{{.name}}\"\nsize = 2\n"
+	err := os.WriteFile(filepath.Join(playbook_base_dir, "file.tpl"), []byte(new_template), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The generated file was edited by hand after the generation
+	err = os.WriteFile(filepath.Join(playbook_base_dir, "out.tf"), []byte("# managed by hand\nname = \"web\"\nsize = 1\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	generation := NewGeneration("playbook.yaml", map[string]interface{}{"name": "web"}, nil)
+	generation.Files = []GeneratedFile{
+		{TemplateFile: "file.tpl", OutputFile: "out.tf", Template: old_template, Mode: OutputModeCreate},
+		{TemplateFile: "missing.tpl", OutputFile: "missing.tf", Template: old_template, Mode: OutputModeCreate},
+	}
+
+	results, err := UpdateGeneration(playbook_base_dir, playbook_base_dir, &generation)
+	if err != nil {
+		t.Fatalf("UpdateGeneration() error = %v", err)
+	}
+	if results[0].Status != UpdateStatusMerged {
+		t.Errorf("UpdateGeneration() status = %v, want %v", results[0].Status, UpdateStatusMerged)
+	}
+	if results[1].Status != UpdateStatusSkipped {
+		t.Errorf("UpdateGeneration() status = %v, want %v", results[1].Status, UpdateStatusSkipped)
+	}
+
+	got, err := os.ReadFile(filepath.Join(playbook_base_dir, "out.tf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# managed by hand\nname = \"web\"\nsize = 2\n"
+	if string(got) != want {
+		t.Errorf("UpdateGeneration() wrote %q, want %q", got, want)
+	}
+	if generation.Files[0].Template != new_template {
+		t.Errorf("UpdateGeneration() did not record the new template version")
+	}
+}
+
+func TestUpdateGenerationHookOutput(t *testing.T) {
+	playbook_base_dir := t.TempDir()
+	old_template := "name=\"{{.name}}\"\nsize=1\n"
+	hooked := "name = \"web\"\nsize = 1\n"
+	generation := NewGeneration("playbook.yaml", map[string]interface{}{"name": "web"}, nil)
+	generation.Files = []GeneratedFile{
+		{TemplateFile: "file.tpl", OutputFile: "out.tf", Template: old_template, Output: hooked, Mode: OutputModeCreate},
+	}
+	write := func(name string, contents string) {
+		if err := os.WriteFile(filepath.Join(playbook_base_dir, name), []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("file.tpl", old_template)
+	write("out.tf", hooked)
+
+	// The template is unchanged, so the file written by the hooks is kept
+	results, err := UpdateGeneration(playbook_base_dir, playbook_base_dir, &generation)
+	if err != nil {
+		t.Fatalf("UpdateGeneration() error = %v", err)
+	}
+	if results[0].Status != UpdateStatusUnchanged {
+		t.Errorf("UpdateGeneration() status = %v, want %v", results[0].Status, UpdateStatusUnchanged)
+	}
+	if generation.Files[0].Output != hooked {
+		t.Errorf("UpdateGeneration() output = %q, want %q", generation.Files[0].Output, hooked)
+	}
+
+	// The file written by the hooks is the merge base of a template change
+	write("file.tpl", "name=\"{{.name}}\"\nsize = 2\n")
+	results, err = UpdateGeneration(playbook_base_dir, playbook_base_dir, &generation)
+	if err != nil {
+		t.Fatalf("UpdateGeneration() error = %v", err)
+	}
+	if results[0].Status != UpdateStatusUpdated {
+		t.Errorf("UpdateGeneration() status = %v, want %v", results[0].Status, UpdateStatusUpdated)
+	}
+	got, err := os.ReadFile(filepath.Join(playbook_base_dir, "out.tf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "name=\"web\"\nsize = 2\n"; string(got) != want {
+		t.Errorf("UpdateGeneration() wrote %q, want %q", got, want)
+	}
+	if generation.Files[0].Output != "" {
+		t.Errorf("UpdateGeneration() kept the output %q of the old template", generation.Files[0].Output)
+	}
+}
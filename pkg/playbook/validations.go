@@ -0,0 +1,135 @@
+/*
+Copyright (c) 2023 Peach Pie Labs, LLC.
+*/
+
+package playbook
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// ValidationRule is an entry of the validations of a question. A rule is one
+// of a built-in validation, configured with range, floatRange, validPatterns
+// or validValues like the validation of a question, a regular expression, or
+// bounds on the length of the answer. Message replaces the generic error
+// shown when the rule fails:
+//
+//	validations:
+//	  - validation: domain_name
+//	  - maxLength: 40
+//	    message: the zone name must fit in a 40 character label
+//	  - regex: '^[a-z]'
+//	    message: the zone name must start with a lower case letter
+type ValidationRule struct {
+	Validation    string        `yaml:"validation,omitempty"`
+	Range         *IntegerRange `yaml:"range,omitempty"`
+	FloatRange    *FloatRange   `yaml:"floatRange,omitempty"`
+	ValidPatterns []string      `yaml:"validPatterns,omitempty"`
+	ValidValues   []string      `yaml:"validValues,omitempty"`
+	Regex         string        `yaml:"regex,omitempty"`
+	MinLength     *int          `yaml:"minLength,omitempty"`
+	MaxLength     *int          `yaml:"maxLength,omitempty"`
+	Message       string        `yaml:"message,omitempty"`
+}
+
+func validateValidationRules(question Question) error {
+	for i, rule := range question.Validations {
+		name := fmt.Sprintf("validation %d of question %s", i+1, question.VariableName)
+		kinds := 0
+		if rule.Validation != "" {
+			kinds++
+		}
+		if rule.Regex != "" {
+			kinds++
+		}
+		if rule.MinLength != nil || rule.MaxLength != nil {
+			kinds++
+		}
+		if kinds != 1 {
+			return fmt.Errorf("%s must have exactly one of validation, regex, or minLength and maxLength", name)
+		}
+		if (rule.Range != nil || rule.FloatRange != nil || rule.ValidPatterns != nil || rule.ValidValues != nil) && rule.Validation == "" {
+			return fmt.Errorf("range, floatRange, validPatterns and validValues of %s are only allowed with a validation", name)
+		}
+		if rule.Validation != "" {
+			if err := validateValidationConfig(rule.question(), name); err != nil {
+				return err
+			}
+		}
+		if rule.Regex != "" {
+			if _, err := regexp.Compile(rule.Regex); err != nil {
+				return fmt.Errorf("invalid regex %q in %s: %w", rule.Regex, name, err)
+			}
+		}
+		if (rule.MinLength != nil && *rule.MinLength < 0) || (rule.MaxLength != nil && *rule.MaxLength < 0) {
+			return fmt.Errorf("minLength and maxLength of %s cannot be negative", name)
+		}
+		if rule.MinLength != nil && rule.MaxLength != nil && *rule.MinLength > *rule.MaxLength {
+			return fmt.Errorf("minLength of %s cannot be greater than maxLength", name)
+		}
+	}
+	return nil
+}
+
+// checkValidationRules checks a value against every rule, and reports all
+// the rules it fails.
+func checkValidationRules(value string, rules []ValidationRule) error {
+	var errs []error
+	for _, rule := range rules {
+		if err := checkValidationRule(value, rule); err != nil {
+			if rule.Message != "" {
+				err = errors.New(rule.Message)
+			}
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// question returns a question configured with the validation of the rule.
+func (rule ValidationRule) question() Question {
+	return Question{
+		Validation:    rule.Validation,
+		Range:         rule.Range,
+		FloatRange:    rule.FloatRange,
+		ValidPatterns: rule.ValidPatterns,
+		ValidValues:   rule.ValidValues,
+	}
+}
+
+func checkValidationRule(value string, rule ValidationRule) error {
+	if rule.Validation != "" {
+		return RegexPatternValidate(value, rule.question())
+	}
+	if rule.Regex != "" {
+		return CustomRegexValidate(value, rule.Regex)
+	}
+	length := utf8.RuneCountInString(value)
+	if rule.MinLength != nil && length < *rule.MinLength {
+		return fmt.Errorf("must be at least %d characters long", *rule.MinLength)
+	}
+	if rule.MaxLength != nil && length > *rule.MaxLength {
+		return fmt.Errorf("must be at most %d characters long", *rule.MaxLength)
+	}
+	return nil
+}
+
+// validateValidationConfig checks that the validation of a question is
+// registered, and lets its validator check the range, floatRange,
+// validPatterns and validValues it is given.
+func validateValidationConfig(question Question, name string) error {
+	validator, ok := lookupValidator(question.Validation)
+	if !ok {
+		return fmt.Errorf("invalid validation %q in %s. valid validations are %s", question.Validation, name, strings.Join(validationNames(), ", "))
+	}
+	if validator, ok := validator.(ConfigValidator); ok {
+		if err := validator.ValidateConfig(question); err != nil {
+			return fmt.Errorf("invalid configuration of validation %s in %s: %w", question.Validation, name, err)
+		}
+	}
+	return nil
+}
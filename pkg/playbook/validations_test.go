@@ -0,0 +1,116 @@
+/*
+Copyright (c) 2023 Peach Pie Labs, LLC.
+*/
+
+package playbook
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateAnswerRules(t *testing.T) {
+	maxLength := 12
+	min, max := 1, 10
+	question := Question{
+		VariableName: "zone_name",
+		Validations: []ValidationRule{
+			{Validation: "domain_name"},
+			{MaxLength: &maxLength, Message: "the zone name must be at most 12 characters"},
+			{Regex: "^[a-z]", Message: "the zone name must start with a lower case letter"},
+		},
+	}
+	tests := []struct {
+		name     string
+		value    string
+		question Question
+		wantErrs []string
+	}{
+		{name: "valid", value: "example.com", question: question},
+		{name: "one_failure", value: "example.co.uk", question: question, wantErrs: []string{"at most 12 characters"}},
+		{name: "all_failures", value: "1-very-long-name", question: question, wantErrs: []string{"regex pattern validation failed", "at most 12 characters", "lower case letter"}},
+		{name: "default_message", value: "ab", question: Question{Validations: []ValidationRule{{MinLength: &min}, {MinLength: &maxLength}}}, wantErrs: []string{"at least 12 characters long"}},
+		{name: "integer_range", value: "11", question: Question{Validations: []ValidationRule{{Validation: "integer_range", Range: &IntegerRange{Min: &min, Max: &max}}}}, wantErrs: []string{"validation failed"}},
+		{name: "with_validation", value: "abc", question: Question{Validation: "email", Validations: []ValidationRule{{MinLength: &min}}}, wantErrs: []string{"validation failed"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateAnswer(tt.value, tt.question)
+			if (err != nil) != (len(tt.wantErrs) > 0) {
+				t.Fatalf("ValidateAnswer() error = %v, want %v", err, tt.wantErrs)
+			}
+			for _, want := range tt.wantErrs {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("ValidateAnswer() error = %q, want it to contain %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateValidationRules(t *testing.T) {
+	one, two := 1, 2
+	tests := []struct {
+		name    string
+		rules   []ValidationRule
+		wantErr bool
+	}{
+		{name: "valid", rules: []ValidationRule{{Validation: "url", ValidPatterns: []string{"https"}}, {MinLength: &one, MaxLength: &two}, {Regex: "^a"}}},
+		{name: "empty", rules: []ValidationRule{{Message: "required"}}, wantErr: true},
+		{name: "two_kinds", rules: []ValidationRule{{Validation: "email", Regex: "^a"}}, wantErr: true},
+		{name: "unknown_validation", rules: []ValidationRule{{Validation: "phone"}}, wantErr: true},
+		{name: "url_without_patterns", rules: []ValidationRule{{Validation: "url"}}, wantErr: true},
+		{name: "patterns_without_url", rules: []ValidationRule{{Validation: "email", ValidPatterns: []string{"https"}}}, wantErr: true},
+		{name: "range_without_validation", rules: []ValidationRule{{Regex: "^a", Range: &IntegerRange{Min: &one, Max: &two}}}, wantErr: true},
+		{name: "inverted_range", rules: []ValidationRule{{Validation: "integer_range", Range: &IntegerRange{Min: &two, Max: &one}}}, wantErr: true},
+		{name: "invalid_regex", rules: []ValidationRule{{Regex: "("}}, wantErr: true},
+		{name: "inverted_length", rules: []ValidationRule{{MinLength: &two, MaxLength: &one}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateValidationRules(Question{VariableName: "name", Validations: tt.rules})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateValidationRules() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateValidationConfig(t *testing.T) {
+	intPointer := func(n int) *int { return &n }
+	floatPointer := func(n float64) *float64 { return &n }
+	tests := []struct {
+		name     string
+		question Question
+		wantErr  bool
+	}{
+		{name: "no_config", question: Question{Validation: "uuid"}},
+		{name: "unknown", question: Question{Validation: "phone"}, wantErr: true},
+		{name: "cidr", question: Question{Validation: "cidr", ValidPatterns: []string{"ipv4"}, Range: &IntegerRange{Min: intPointer(16), Max: intPointer(28)}}},
+		{name: "cidr_invalid_version", question: Question{Validation: "cidr", ValidPatterns: []string{"ipv5"}}, wantErr: true},
+		{name: "cidr_prefix_too_long", question: Question{Validation: "cidr", Range: &IntegerRange{Max: intPointer(129)}}, wantErr: true},
+		{name: "cidr_ipv4_prefix_too_long", question: Question{Validation: "cidr", ValidPatterns: []string{"ipv4"}, Range: &IntegerRange{Max: intPointer(48)}}, wantErr: true},
+		{name: "cidr_ipv6_prefix", question: Question{Validation: "cidr", ValidPatterns: []string{"ipv4", "ipv6"}, Range: &IntegerRange{Max: intPointer(48)}}},
+		{name: "port_range", question: Question{Validation: "port", Range: &IntegerRange{Min: intPointer(1024), Max: intPointer(65535)}}},
+		{name: "port_range_inverted", question: Question{Validation: "port", Range: &IntegerRange{Min: intPointer(9000), Max: intPointer(8000)}}, wantErr: true},
+		{name: "range_not_allowed", question: Question{Validation: "hostname", Range: &IntegerRange{Max: intPointer(10)}}, wantErr: true},
+		{name: "patterns_not_allowed", question: Question{Validation: "uuid", ValidPatterns: []string{"v4"}}, wantErr: true},
+		{name: "arn_services", question: Question{Validation: "arn", ValidPatterns: []string{"iam", "s3"}}},
+		{name: "length_without_range", question: Question{Validation: "length"}, wantErr: true},
+		{name: "integer_range_without_max", question: Question{Validation: "integer_range", Range: &IntegerRange{Min: intPointer(1)}}, wantErr: true},
+		{name: "float_range", question: Question{Validation: "float_range", FloatRange: &FloatRange{Max: floatPointer(0.5)}}},
+		{name: "float_range_missing", question: Question{Validation: "float_range"}, wantErr: true},
+		{name: "float_range_not_allowed", question: Question{Validation: "port", FloatRange: &FloatRange{Max: floatPointer(0.5)}}, wantErr: true},
+		{name: "one_of", question: Question{Validation: "one_of", InputType: "textfield", ValidValues: []string{"dev"}}},
+		{name: "one_of_without_values", question: Question{Validation: "one_of"}, wantErr: true},
+		{name: "values_not_allowed", question: Question{Validation: "email", InputType: "textfield", ValidValues: []string{"a@b.c"}}, wantErr: true},
+		{name: "select_values", question: Question{Validation: "hostname", InputType: "select", ValidValues: []string{"web"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateValidationConfig(tt.question, "question name"); (err != nil) != tt.wantErr {
+				t.Errorf("validateValidationConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,243 @@
+/*
+Copyright (c) 2023 Peach Pie Labs, LLC.
+*/
+
+package playbook
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+)
+
+// Validator checks an answer for a named validation. The question carries the
+// configuration of the validation: range, floatRange, validPatterns and
+// validValues, whether they were set on the question or on a rule of its
+// validations.
+type Validator interface {
+	Validate(value string, question Question) error
+}
+
+// ConfigValidator is implemented by validators that check their configuration
+// when a playbook is validated. The configuration of other validators is not
+// checked.
+type ConfigValidator interface {
+	Validator
+	ValidateConfig(question Question) error
+}
+
+// ValidatorFunc turns a function into a Validator.
+type ValidatorFunc func(value string, question Question) error
+
+func (f ValidatorFunc) Validate(value string, question Question) error {
+	return f(value, question)
+}
+
+var validators = struct {
+	sync.RWMutex
+	byName map[string]Validator
+}{byName: map[string]Validator{}}
+
+// RegisterValidator makes a validation available to playbooks under name, at
+// the prompt and for answers given in a targets file or the environment alike.
+// It is meant to be called from an init function of a program embedding the
+// playbook package, e.g.
+//
+//	playbook.RegisterValidator("cost_center", playbook.ValidatorFunc(func(value string, question playbook.Question) error {
+//		if !strings.HasPrefix(value, "CC-") {
+//			return errors.New("cost centers start with CC-")
+//		}
+//		return nil
+//	}))
+//
+// It panics if name is empty, validator is nil, or a validator is already
+// registered under name.
+func RegisterValidator(name string, validator Validator) {
+	if name == "" || validator == nil {
+		panic("playbook: RegisterValidator needs a name and a validator")
+	}
+	validators.Lock()
+	defer validators.Unlock()
+	if _, ok := validators.byName[name]; ok {
+		panic("playbook: RegisterValidator called twice for validation " + name)
+	}
+	validators.byName[name] = validator
+}
+
+// unregisterValidator removes a validation registered by a test.
+func unregisterValidator(name string) {
+	validators.Lock()
+	defer validators.Unlock()
+	delete(validators.byName, name)
+}
+
+func lookupValidator(name string) (Validator, bool) {
+	validators.RLock()
+	defer validators.RUnlock()
+	validator, ok := validators.byName[name]
+	return validator, ok
+}
+
+// validationNames returns the names of the registered validations.
+func validationNames() []string {
+	validators.RLock()
+	defer validators.RUnlock()
+	var names []string
+	for name := range validators.byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// builtinValidator is a built-in validation along with the configuration
+// fields it uses and the checks of their values.
+type builtinValidator struct {
+	validate func(value string, question Question) error
+	fields   []string
+	config   func(question Question) error
+}
+
+func (v builtinValidator) Validate(value string, question Question) error {
+	return v.validate(value, question)
+}
+
+func (v builtinValidator) ValidateConfig(question Question) error {
+	set := map[string]bool{
+		"range":         question.Range != nil,
+		"floatRange":    question.FloatRange != nil,
+		"validPatterns": question.ValidPatterns != nil,
+		// Select questions have validValues for their options
+		"validValues": question.InputType != "select" && question.ValidValues != nil,
+	}
+	for _, field := range v.fields {
+		set[field] = false
+	}
+	for _, field := range []string{"range", "floatRange", "validPatterns", "validValues"} {
+		if set[field] {
+			return fmt.Errorf("%s is not used by this validation", field)
+		}
+	}
+	if v.config == nil {
+		return nil
+	}
+	return v.config(question)
+}
+
+func init() {
+	builtins := map[string]builtinValidator{
+		"domain_name": {validate: checkFlag(validateDomain)},
+		"ip_address":  {validate: checkFlag(validateIP)},
+		"email":       {validate: checkFlag(validateEmail)},
+		"url": {validate: validateURLValue, fields: []string{"validPatterns"}, config: func(question Question) error {
+			return checkPatterns(question, true, "any", "http", "https")
+		}},
+		"integer_range": {validate: validateIntegerRangeValue, fields: []string{"range"}, config: func(question Question) error {
+			return checkRangeConfig(question, math.MinInt, math.MaxInt, requireBoth)
+		}},
+		"one_of": {validate: validateOneOf, fields: []string{"validValues"}, config: func(question Question) error {
+			if len(question.ValidValues) == 0 {
+				return errors.New("validValues is required")
+			}
+			return nil
+		}},
+		"cidr": {validate: validateCIDR, fields: []string{"validPatterns", "range"}, config: func(question Question) error {
+			if err := checkPatterns(question, false, "any", "ipv4", "ipv6"); err != nil {
+				return err
+			}
+			// IPv4 blocks have prefixes of at most 32 bits
+			maxPrefix := 32
+			if len(question.ValidPatterns) == 0 {
+				maxPrefix = 128
+			}
+			for _, pattern := range question.ValidPatterns {
+				if pattern != "ipv4" {
+					maxPrefix = 128
+				}
+			}
+			return checkRangeConfig(question, 0, maxPrefix, requireNone)
+		}},
+		"port": {validate: validatePort, fields: []string{"range"}, config: func(question Question) error {
+			return checkRangeConfig(question, 1, 65535, requireNone)
+		}},
+		"hostname": {validate: validateHostname},
+		"semver":   {validate: validatePattern(semverPattern, "a semantic version, e.g. 1.2.3")},
+		"uuid":     {validate: validatePattern(uuidPattern, "a UUID")},
+		"duration": {validate: validateDuration},
+		"float_range": {validate: validateFloatRange, fields: []string{"floatRange"}, config: func(question Question) error {
+			bounds := question.FloatRange
+			if bounds == nil || (bounds.Min == nil && bounds.Max == nil) {
+				return errors.New("floatRange with min, max or both is required")
+			}
+			if bounds.Min != nil && bounds.Max != nil && *bounds.Min > *bounds.Max {
+				return errors.New("floatRange min cannot be greater than max")
+			}
+			return nil
+		}},
+		"length": {validate: validateLength, fields: []string{"range"}, config: func(question Question) error {
+			return checkRangeConfig(question, 0, math.MaxInt, requireEither)
+		}},
+		"json": {validate: validateJSONValue},
+		"arn": {validate: validateARN, fields: []string{"validPatterns"}, config: func(question Question) error {
+			// Any service may be given
+			return checkPatterns(question, false)
+		}},
+		"gcp_region": {validate: validatePattern(gcpRegionPattern, "a GCP region, e.g. us-central1")},
+		"aws_region": {validate: validatePattern(awsRegionPattern, "an AWS region, e.g. us-east-1")},
+	}
+	for name, validator := range builtins {
+		RegisterValidator(name, validator)
+	}
+}
+
+// checkPatterns checks the validPatterns of a question against the allowed
+// values, or only that they are not empty when no values are listed.
+func checkPatterns(question Question, required bool, allowed ...string) error {
+	if required && len(question.ValidPatterns) == 0 {
+		return errors.New("validPatterns is required")
+	}
+	for _, pattern := range question.ValidPatterns {
+		valid := len(allowed) == 0 && pattern != ""
+		for _, allowedPattern := range allowed {
+			if pattern == allowedPattern {
+				valid = true
+			}
+		}
+		if !valid {
+			return fmt.Errorf("invalid validPatterns value %q", pattern)
+		}
+	}
+	return nil
+}
+
+const (
+	requireNone = iota
+	requireEither
+	requireBoth
+)
+
+// checkRangeConfig checks that the range of a question has the bounds required
+// and lies between min and max.
+func checkRangeConfig(question Question, min int, max int, required int) error {
+	bounds := question.Range
+	if bounds == nil {
+		bounds = &IntegerRange{}
+	}
+	if required == requireBoth && (bounds.Min == nil || bounds.Max == nil) {
+		return errors.New("range with min and max is required")
+	}
+	if required == requireEither && bounds.Min == nil && bounds.Max == nil {
+		return errors.New("range with min, max or both is required")
+	}
+	for _, bound := range []*int{bounds.Min, bounds.Max} {
+		if bound != nil && (*bound < min || *bound > max) {
+			return fmt.Errorf("range must be between %d and %d", min, max)
+		}
+	}
+	if bounds.Min != nil && bounds.Max != nil && *bounds.Min > *bounds.Max {
+		return errors.New("range min cannot be greater than max")
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+/*
+Copyright (c) 2023 Peach Pie Labs, LLC.
+*/
+
+package playbook
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type costCenterValidator struct{}
+
+func (costCenterValidator) Validate(value string, question Question) error {
+	for _, prefix := range question.ValidPatterns {
+		if strings.HasPrefix(value, prefix) {
+			return nil
+		}
+	}
+	return errors.New("unknown cost center")
+}
+
+func (costCenterValidator) ValidateConfig(question Question) error {
+	if len(question.ValidPatterns) == 0 {
+		return errors.New("validPatterns is required")
+	}
+	return nil
+}
+
+func TestRegisterValidator(t *testing.T) {
+	RegisterValidator("test_cost_center", costCenterValidator{})
+	RegisterValidator("test_even_length", ValidatorFunc(func(value string, question Question) error {
+		if len(value)%2 != 0 {
+			return errors.New("odd length")
+		}
+		return nil
+	}))
+	t.Cleanup(func() {
+		unregisterValidator("test_cost_center")
+		unregisterValidator("test_even_length")
+	})
+
+	question := Question{VariableName: "cost_center", Validation: "test_cost_center", ValidPatterns: []string{"CC-"}}
+	if err := ValidateAnswer("CC-100", question); err != nil {
+		t.Errorf("ValidateAnswer() error = %v", err)
+	}
+	if err := ValidateAnswer("XX-100", question); err == nil {
+		t.Error("ValidateAnswer() accepted an unknown cost center")
+	}
+	rules := Question{VariableName: "team", Validations: []ValidationRule{{Validation: "test_even_length"}}}
+	if err := ValidateAnswer("abc", rules); err == nil {
+		t.Error("ValidateAnswer() accepted an answer failing a registered rule")
+	}
+
+	if err := validateValidationConfig(question, "question cost_center"); err != nil {
+		t.Errorf("validateValidationConfig() error = %v", err)
+	}
+	if err := validateValidationConfig(Question{Validation: "test_cost_center"}, "question cost_center"); err == nil {
+		t.Error("validateValidationConfig() accepted a missing configuration")
+	}
+	// Validators without ValidateConfig accept any configuration
+	if err := validateValidationConfig(Question{Validation: "test_even_length", ValidPatterns: []string{"x"}}, "question team"); err != nil {
+		t.Errorf("validateValidationConfig() error = %v", err)
+	}
+	if !strings.Contains(strings.Join(validationNames(), ","), "test_cost_center") {
+		t.Errorf("validationNames() = %v", validationNames())
+	}
+}
+
+func TestRegisterValidatorPanics(t *testing.T) {
+	tests := []struct {
+		name          string
+		validatorName string
+		validator     Validator
+	}{
+		{name: "duplicate", validatorName: "email", validator: ValidatorFunc(func(string, Question) error { return nil })},
+		{name: "empty_name", validatorName: "", validator: ValidatorFunc(func(string, Question) error { return nil })},
+		{name: "nil_validator", validatorName: "test_nil"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("RegisterValidator() did not panic")
+				}
+			}()
+			RegisterValidator(tt.validatorName, tt.validator)
+		})
+	}
+}
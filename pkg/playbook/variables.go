@@ -0,0 +1,206 @@
+/*
+Copyright (c) 2023 Peach Pie Labs, LLC.
+*/
+
+package playbook
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+	"text/template/parse"
+
+	"github.com/Masterminds/sprig/v3"
+)
+
+// Variables are computed from the answers. Each value is a template that may
+// refer to answers and to other variables.
+type Variables map[string]string
+
+// templateFields returns the names of the top-level fields a template refers
+// to, such as cluster_name for {{ .cluster_name | lower }}.
+func templateFields(text string) ([]string, error) {
+	tmpl, err := texttemplate.New("variable").Funcs(sprig.TxtFuncMap()).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]bool)
+	var walk func(node parse.Node)
+	walk = func(node parse.Node) {
+		switch node := node.(type) {
+		case *parse.ListNode:
+			if node == nil {
+				return
+			}
+			for _, child := range node.Nodes {
+				walk(child)
+			}
+		case *parse.ActionNode:
+			walk(node.Pipe)
+		case *parse.PipeNode:
+			if node == nil {
+				return
+			}
+			for _, command := range node.Cmds {
+				walk(command)
+			}
+		case *parse.CommandNode:
+			for _, arg := range node.Args {
+				walk(arg)
+			}
+		case *parse.FieldNode:
+			fields[node.Ident[0]] = true
+		case *parse.VariableNode:
+			if node.Ident[0] == "$" && len(node.Ident) > 1 {
+				fields[node.Ident[1]] = true
+			}
+		case *parse.ChainNode:
+			walk(node.Node)
+		case *parse.IfNode:
+			walk(&node.BranchNode)
+		case *parse.RangeNode:
+			walk(&node.BranchNode)
+		case *parse.WithNode:
+			walk(&node.BranchNode)
+		case *parse.BranchNode:
+			walk(node.Pipe)
+			walk(node.List)
+			walk(node.ElseList)
+		case *parse.TemplateNode:
+			walk(node.Pipe)
+		}
+	}
+	if tmpl.Tree != nil {
+		walk(tmpl.Tree.Root)
+	}
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Order returns the variable names so that every variable comes after the
+// variables it refers to. It fails when variables refer to each other in a cycle.
+func (v Variables) Order() ([]string, error) {
+	dependencies := make(map[string][]string, len(v))
+	for name, text := range v {
+		fields, err := templateFields(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid variable %s: %w", name, err)
+		}
+		for _, field := range fields {
+			if _, ok := v[field]; ok {
+				dependencies[name] = append(dependencies[name], field)
+			}
+		}
+	}
+
+	names := make([]string, 0, len(v))
+	for name := range v {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int, len(v))
+	var order []string
+	var path []string
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			start := 0
+			for i, previous := range path {
+				if previous == name {
+					start = i
+				}
+			}
+			return fmt.Errorf("variables depend on each other in a cycle: %s -> %s", strings.Join(path[start:], " -> "), name)
+		}
+		state[name] = visiting
+		path = append(path, name)
+		for _, dependency := range dependencies[name] {
+			if err := visit(dependency); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = visited
+		order = append(order, name)
+		return nil
+	}
+	for _, name := range names {
+		if err := visit(name); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+func validateVariables(playbook Playbook) error {
+	for _, question := range playbook.Questions {
+		if _, ok := playbook.Variables[question.VariableName]; ok {
+			return fmt.Errorf("variable %s is also the variableName of a question", question.VariableName)
+		}
+	}
+	_, err := playbook.Variables.Order()
+	return err
+}
+
+// EvaluateVariables adds to input_data every variable of the playbook that is
+// not set yet and whose answers and variables are all available, in
+// dependency order. It is called after each answer, so that variables can be
+// used by the questions that follow, and once every question is answered all
+// variables are set. Fields that are neither questions nor variables, such as
+// answers from a targets file, are not waited for.
+func EvaluateVariables(playbook Playbook, input_data map[string]interface{}) error {
+	order, err := playbook.Variables.Order()
+	if err != nil {
+		return err
+	}
+	pending := make(map[string]bool)
+	for _, question := range playbook.Questions {
+		if _, ok := input_data[question.VariableName]; !ok {
+			pending[question.VariableName] = true
+		}
+	}
+	for _, name := range order {
+		if _, ok := input_data[name]; !ok {
+			pending[name] = true
+		}
+	}
+
+	for _, name := range order {
+		if !pending[name] {
+			continue
+		}
+		fields, err := templateFields(playbook.Variables[name])
+		if err != nil {
+			return fmt.Errorf("invalid variable %s: %w", name, err)
+		}
+		ready := true
+		for _, field := range fields {
+			if pending[field] {
+				ready = false
+			}
+		}
+		if !ready {
+			continue
+		}
+		value, err := RenderText(playbook.Variables[name], input_data)
+		if err != nil {
+			return fmt.Errorf("invalid variable %s: %w", name, err)
+		}
+		input_data[name] = value
+		delete(pending, name)
+	}
+	return nil
+}
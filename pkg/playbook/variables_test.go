@@ -0,0 +1,88 @@
+/*
+Copyright (c) 2023 Peach Pie Labs, LLC.
+*/
+
+package playbook
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestVariablesOrder(t *testing.T) {
+	tests := []struct {
+		name      string
+		variables Variables
+		want      []string
+		wantErr   bool
+	}{
+		{
+			name: "dependency_order",
+			variables: Variables{
+				"bucket": `{{ .slug }}-{{ .env }}-logs`,
+				"slug":   `{{ .cluster_name | lower | replace "_" "-" }}`,
+				"env":    `{{ if eq .environment "production" }}prod{{ else }}{{ .environment }}{{ end }}`,
+			},
+			want: []string{"env", "slug", "bucket"},
+		},
+		{
+			name: "cycle",
+			variables: Variables{
+				"a": "{{ .b }}",
+				"b": "{{ .c }}",
+				"c": "{{ $.a }}",
+			},
+			wantErr: true,
+		},
+		{
+			name:      "self_reference",
+			variables: Variables{"a": "{{ .a }}-x"},
+			wantErr:   true,
+		},
+		{
+			name:      "invalid_template",
+			variables: Variables{"a": "{{ .b "},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.variables.Order()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Order() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) && !tt.wantErr {
+				t.Errorf("Order() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateVariables(t *testing.T) {
+	playbook := Playbook{
+		Questions: []Question{{VariableName: "cluster_name"}, {VariableName: "environment"}},
+		Variables: Variables{
+			"slug":   `{{ .cluster_name | lower | replace "_" "-" }}`,
+			"bucket": `{{ .slug }}-{{ .environment }}-logs`,
+			"owner":  `{{ .team | default "platform" }}`,
+		},
+	}
+
+	// Variables are computed as soon as their answers are known
+	input_data := map[string]interface{}{"cluster_name": "Web_Cluster"}
+	if err := EvaluateVariables(playbook, input_data); err != nil {
+		t.Fatalf("EvaluateVariables() error = %v", err)
+	}
+	want := map[string]interface{}{"cluster_name": "Web_Cluster", "slug": "web-cluster", "owner": "platform"}
+	if !reflect.DeepEqual(input_data, want) {
+		t.Errorf("EvaluateVariables() = %v, want %v", input_data, want)
+	}
+
+	input_data["environment"] = "dev"
+	if err := EvaluateVariables(playbook, input_data); err != nil {
+		t.Fatalf("EvaluateVariables() error = %v", err)
+	}
+	if input_data["bucket"] != "web-cluster-dev-logs" {
+		t.Errorf("EvaluateVariables() bucket = %v, want %v", input_data["bucket"], "web-cluster-dev-logs")
+	}
+}